@@ -0,0 +1,1034 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	fazrep "github.com/slayerjk/faz-get-reports/internal/fazrequests"
+	"github.com/slayerjk/faz-get-reports/internal/httpclient"
+	"github.com/slayerjk/faz-get-reports/internal/ldapresolver"
+	"github.com/slayerjk/faz-get-reports/internal/mailer"
+	models "github.com/slayerjk/faz-get-reports/internal/models"
+	"github.com/slayerjk/faz-get-reports/internal/netconf"
+	"github.com/slayerjk/faz-get-reports/internal/period"
+	"github.com/slayerjk/faz-get-reports/internal/secrets"
+	"github.com/slayerjk/faz-get-reports/internal/sumparser"
+	"github.com/slayerjk/faz-get-reports/internal/ticketing"
+)
+
+// there are no unprocessed values in db(mode 'naumen')
+var errNoValues = errors.New("no values to process")
+
+// actions on failed ticket(naumen-data.json 'ticketing.on-failure')
+const (
+	ticketFailureComment = "comment"
+	ticketFailureReject  = "reject"
+)
+
+// format of run id(time of run start)
+const runIdLayout = "20060102-150405"
+
+// run was interrupted by shutdown before all users were processed
+var errInterrupted = errors.New("interrupted by shutdown")
+
+// application settings & state shared by one-shot run and serve mode
+type app struct {
+	logger     *slog.Logger
+	dbModel    *models.DbModel
+	dsn        string
+	mode       string
+	naumenMode bool
+	mailingOpt bool
+	// solution text for HD Request
+	solutionText string
+
+	// unified config file & legacy data files(faz, naumen, ldap, mailing)
+	config configSource
+	// jobs of mode 'csv': users.csv, jobs file, stdin or command line args
+	jobSource   jobSource
+	resultsPath string
+
+	// validate & resolve users in AD
+	ldapOpt bool
+	// report of AD group entry: groupReportMembers or groupReportCombined
+	groupReport string
+
+	// time zone of requested periods without explicit time zone
+	requesterLocation *time.Location
+	// split period of user into slices: period.SplitDay/SplitWeek/SplitMonth(SplitNone - don't split)
+	splitPeriod string
+	// deliver slice reports as one archive
+	mergeSlices bool
+	// retention of delivered report files, they are purged after it
+	keepReports time.Duration
+	// report file path template(relative to resultsPath)
+	fileTemplate *template.Template
+	// id of current run(time of run start), for file template
+	runId string
+
+	// resolver of credential references of data files('env:', 'file:', 'keystore:')
+	secretResolver *secrets.Resolver
+
+	// config content, see loadConfig
+	// http client of FAZ(TLS settings of data file)
+	fazClient *http.Client
+	// helpdesk API of naumen modes(Naumen or REST, see naumen-data.json 'ticketing')
+	helpdesk    ticketing.Ticketing
+	fazModel    *fazrep.FazModelJson
+	fazLocation *time.Location
+	naumenData  naumenData
+	ldapConfig  ldapresolver.Config
+}
+
+// log error and mail it if mailing option is on
+func (a *app) reportError(msg string) {
+	msg = secrets.Redact(msg)
+	if a.mailingOpt {
+		if mailErr := a.sendMail("error", msg); mailErr != nil {
+			a.logger.Warn("failed to send email", slog.Any("ERR", mailErr))
+		}
+	}
+	a.logger.Error(msg)
+}
+
+// log report and mail it if mailing option is on
+func (a *app) reportSuccess(msg string) {
+	msg = secrets.Redact(msg)
+	if a.mailingOpt {
+		if mailErr := a.sendMail("report", msg); mailErr != nil {
+			a.logger.Warn("failed to send email", slog.Any("ERR", mailErr))
+		}
+	}
+	a.logger.Info(msg)
+}
+
+// mail msg of msgType('error' or 'report'), mailing settings are read on every send(config may be broken)
+func (a *app) sendMail(msgType, msg string) error {
+	mailData, err := a.loadMailing()
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(*mailData, msgType, appName, []byte(msg))
+}
+
+// read mailing settings & resolve secret references of its credentials & proxy
+func (a *app) loadMailing() (*mailer.MailData, error) {
+	mailData, err := a.config.loadMailing()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.resolveMailing(mailData); err != nil {
+		return nil, err
+	}
+	return mailData, nil
+}
+
+// resolve secret references of SMTP password & smtp-network proxy
+func (a *app) resolveMailing(mailData *mailer.MailData) error {
+	if err := a.secretResolver.ResolveFields(map[string]*string{"auth_pass": &mailData.AuthPass}); err != nil {
+		return fmt.Errorf("failed to resolve mailing credentials:\n\t%v", err)
+	}
+	if err := a.resolveProxy(&mailData.Network); err != nil {
+		return fmt.Errorf("failed to resolve smtp-network proxy:\n\t%v", err)
+	}
+	return nil
+}
+
+// required config sections: FAZ(and NAUMEN for naumen modes, LDAP for '-ldap', mailing for '-m')
+func (a *app) requiredSections() []string {
+	required := []string{sectionFaz}
+	if a.naumenMode {
+		required = append(required, sectionNaumen)
+	}
+	if a.ldapOpt {
+		required = append(required, sectionLdap)
+	}
+	if a.mailingOpt {
+		required = append(required, sectionMailing)
+	}
+	return required
+}
+
+// read config sections of unified config file or of legacy data files(see configSource)
+//
+// app data is replaced only if all sections were read successfully
+func (a *app) loadConfig() error {
+	sections, err := a.config.load(a.requiredSections()...)
+	if err != nil {
+		return fmt.Errorf("FAILURE: read config:\n\t%v", err)
+	}
+
+	// keystore may be changed since previous load(SIGHUP of serve mode)
+	a.secretResolver.Reset()
+
+	fazModel := sections.Faz
+	if err := a.secretResolver.ResolveFields(map[string]*string{"api-user-pass": &fazModel.ApiUserPass, "api-token": &fazModel.ApiToken}); err != nil {
+		return fmt.Errorf("FAILURE: resolve FAZ credentials:\n\t%v", err)
+	}
+	if err := a.resolveProxy(&fazModel.FazNetwork); err != nil {
+		return fmt.Errorf("FAILURE: resolve faz-network proxy:\n\t%v", err)
+	}
+
+	// FAZ time zone
+	fazLocation := time.Local
+	if fazModel.FazTimezone != "" {
+		fazLocation, err = time.LoadLocation(fazModel.FazTimezone)
+		if err != nil {
+			return fmt.Errorf("FAILURE: load FAZ time zone(%s):\n\t%v", fazModel.FazTimezone, err)
+		}
+	}
+
+	var naumenData naumenData
+	if a.naumenMode {
+		naumenData = *sections.Naumen
+		if err := a.secretResolver.ResolveFields(map[string]*string{"naumen-access-key": &naumenData.NaumenAccessKey}); err != nil {
+			return fmt.Errorf("FAILURE: resolve NAUMEN credentials:\n\t%v", err)
+		}
+		if err := a.resolveProxy(&naumenData.NaumenNetwork); err != nil {
+			return fmt.Errorf("FAILURE: resolve naumen-network proxy:\n\t%v", err)
+		}
+		if err := sumparser.Validate(naumenData.NaumenTemplates); err != nil {
+			return fmt.Errorf("FAILURE: check NAUMEN templates:\n\t%v", err)
+		}
+		if a.mode == "naumen-discovery" && (naumenData.Ticketing.Type == "" || naumenData.Ticketing.Type == "naumen") {
+			if err := naumenData.NaumenDiscovery.Validate(); err != nil {
+				return fmt.Errorf("FAILURE: check NAUMEN discovery filter:\n\t%v", err)
+			}
+		}
+	}
+
+	var ldapConfig ldapresolver.Config
+	if a.ldapOpt {
+		ldapConfig = *sections.Ldap
+		if err := a.secretResolver.ResolveFields(map[string]*string{"ldap-bind-pass": &ldapConfig.BindPass}); err != nil {
+			return fmt.Errorf("FAILURE: resolve LDAP credentials:\n\t%v", err)
+		}
+	}
+
+	if a.mailingOpt {
+		if err := a.resolveMailing(sections.Mailing); err != nil {
+			return fmt.Errorf("FAILURE: resolve mailing settings:\n\t%v", err)
+		}
+		if err := sections.Mailing.Network.Validate(); err != nil {
+			return fmt.Errorf("FAILURE: check mailing smtp-network settings:\n\t%v", err)
+		}
+	}
+
+	// HTTP CLIENTS
+	fazClient, err := a.newClient("FAZ", fazModel.FazTLS, fazModel.FazNetwork)
+	if err != nil {
+		return err
+	}
+	var helpdesk ticketing.Ticketing
+	if a.naumenMode {
+		naumenClient, err := a.newClient("NAUMEN", naumenData.NaumenTLS, naumenData.NaumenNetwork)
+		if err != nil {
+			return err
+		}
+		helpdesk, err = newHelpdesk(naumenClient, naumenData)
+		if err != nil {
+			return fmt.Errorf("FAILURE: check NAUMEN ticketing settings:\n\t%v", err)
+		}
+	}
+
+	a.fazClient = fazClient
+	a.helpdesk = helpdesk
+	a.fazModel = fazModel
+	a.ldapConfig = ldapConfig
+	a.fazLocation = fazLocation
+	a.naumenData = naumenData
+
+	return nil
+}
+
+// helpdesk API of naumen section: Naumen or REST/webhook API
+func newHelpdesk(client *http.Client, naumenData naumenData) (ticketing.Ticketing, error) {
+	switch naumenData.Ticketing.OnFailure {
+	case "", ticketFailureComment, ticketFailureReject:
+	default:
+		return nil, fmt.Errorf("wrong 'on-failure'(%s), must be '%s' or '%s'", naumenData.Ticketing.OnFailure, ticketFailureComment, ticketFailureReject)
+	}
+
+	switch naumenData.Ticketing.Type {
+	case "", "naumen":
+		// Naumen has no common reject transition(it depends on workflow)
+		if naumenData.Ticketing.OnFailure == ticketFailureReject {
+			return nil, fmt.Errorf("'on-failure' '%s' isn't supported by type 'naumen', use '%s'", ticketFailureReject, ticketFailureComment)
+		}
+		return ticketing.NewNaumen(client, naumenData.NaumenBaseUrl, naumenData.NaumenAccessKey, naumenData.NaumenDiscovery), nil
+	case "rest":
+		rest := naumenData.Ticketing.Rest
+		// endpoint of failure action must be set
+		switch {
+		case naumenData.Ticketing.OnFailure == ticketFailureComment && rest.Comment.URL == "":
+			return nil, fmt.Errorf("'on-failure' is '%s', but 'rest.comment' URL is empty", ticketFailureComment)
+		case naumenData.Ticketing.OnFailure == ticketFailureReject && rest.Reject.URL == "":
+			return nil, fmt.Errorf("'on-failure' is '%s', but 'rest.reject' URL is empty", ticketFailureReject)
+		}
+		return ticketing.NewRest(client, naumenData.NaumenBaseUrl, naumenData.NaumenAccessKey, rest)
+	}
+
+	return nil, fmt.Errorf("unknown type(%s), must be 'naumen' or 'rest'", naumenData.Ticketing.Type)
+}
+
+// resolve secret references of proxy URL: whole URL(ex.: 'env:FAZ_PROXY') or its password(ex.: 'http://user:keystore:proxy-pass@host:3128')
+//
+// proxy password is registered for redaction(also plain one)
+func (a *app) resolveProxy(network *netconf.Settings) error {
+	if network.Proxy == "" || network.Proxy == "env" {
+		return nil
+	}
+
+	proxy, err := a.secretResolver.Resolve(network.Proxy)
+	if err != nil {
+		return err
+	}
+
+	// parse error contains URL, so it's not shown
+	proxyUrl, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("proxy is not valid URL")
+	}
+	if proxyUrl.User == nil {
+		network.Proxy = proxy
+		return nil
+	}
+
+	password, ok := proxyUrl.User.Password()
+	if ok {
+		password, err = a.secretResolver.Resolve(password)
+		if err != nil {
+			return fmt.Errorf("proxy password: %v", err)
+		}
+		proxyUrl.User = url.UserPassword(proxyUrl.User.Username(), password)
+		// password is escaped in URL
+		secrets.Register(password, strings.TrimPrefix(url.UserPassword("", password).String(), ":"))
+	}
+	network.Proxy = proxyUrl.String()
+
+	return nil
+}
+
+// http client of endpoint, insecure TLS is warned on every config load
+func (a *app) newClient(endpoint string, tlsSettings httpclient.TLS, network netconf.Settings) (*http.Client, error) {
+	client, err := httpclient.New(tlsSettings, network)
+	if err != nil {
+		return nil, fmt.Errorf("FAILURE: make %s http client:\n\t%v", endpoint, err)
+	}
+
+	if tlsSettings.Insecure {
+		a.logger.Warn("!!! TLS CERTIFICATE VERIFICATION IS DISABLED('insecure'), credentials may be sent to anyone answering !!!", "ENDPOINT", endpoint)
+	}
+
+	return client, nil
+}
+
+// parser of report periods(ticket & users file) in requester time zone with FAZ log retention of FAZ data file
+func (a *app) periodParser() period.Parser {
+	return period.Parser{
+		Location:  a.requesterLocation,
+		Retention: time.Duration(a.fazModel.FazLogRetentionDays) * 24 * time.Hour,
+	}
+}
+
+// one full processing cycle: collect users, get FAZ reports & deliver them(mode 'naumen')
+//
+// on ctx cancel current report job is finished and remaining users are skipped(errInterrupted)
+func (a *app) run(ctx context.Context) error {
+	var users []User
+
+	// Naumen tickets of run grouped by service call(RP, SC, files report)
+	tickets := newNaumenTickets()
+
+	// CREATING REPORTS DIR IF NOT EXIST
+	if err := os.MkdirAll(a.resultsPath, os.ModePerm); err != nil {
+		return fmt.Errorf("FAILURE: create reports dir(%s):\n\t%v", a.resultsPath, err)
+	}
+
+	a.runId = time.Now().Format(runIdLayout)
+
+	// delete delivered reports which retention is over(also if there is nothing to process)
+	defer a.purgeOutputs()
+
+	// different workflows for modes 'naumen'(default) & 'csv'
+	switch {
+	case a.naumenMode:
+		naumenUsers, err := a.naumenUsers(tickets)
+		if err != nil {
+			return err
+		}
+		users = naumenUsers
+	case a.mode == "csv":
+		localUsers, err := a.localUsers()
+		if err != nil {
+			return err
+		}
+		users = localUsers
+	default:
+		return fmt.Errorf("FAILURE: unknown mode: %s", a.mode)
+	}
+
+	// validate users & resolve their names in AD
+	if a.ldapOpt {
+		resolvedUsers, err := a.resolveUsers(users, tickets)
+		if err != nil {
+			return err
+		}
+		users = resolvedUsers
+	} else {
+		checkedUsers, err := a.rejectGroups(users, tickets)
+		if err != nil {
+			return err
+		}
+		users = checkedUsers
+	}
+
+	if err := a.getReports(ctx, users, tickets); err != nil {
+		return err
+	}
+
+	// if mode 'naumen' - attach collected reports, close ticket(set wait for acceptance)
+	if a.naumenMode {
+		// reports of tickets are generated again: not delivered ones of previous runs aren't needed anymore
+		a.purgePreviousOutputs(tickets)
+
+		if err := a.deliverNaumen(tickets); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// get users of unprocessed db values(find new Naumen service calls first in mode 'naumen-discovery')
+func (a *app) naumenUsers(tickets *naumenTickets) ([]User, error) {
+	var (
+		user  User
+		users []User
+	)
+
+	// requests of db values fetched in this run, nil if fetch failed
+	requests := make(map[string]*ticketing.Request)
+
+	// find new service calls in Naumen and add them to db
+	if a.mode == "naumen-discovery" {
+		a.logger.Info("started finding new Naumen service calls", slog.Any("FILTER", a.naumenData.NaumenDiscovery))
+
+		finder, ok := a.helpdesk.(ticketing.Finder)
+		if !ok {
+			return nil, fmt.Errorf("FAILURE: find new Naumen service calls: helpdesk API can't find requests")
+		}
+		foundServiceCalls, err := finder.Find()
+		if err != nil {
+			return nil, fmt.Errorf("FAILURE: find new Naumen service calls:\n\t%v", err)
+		}
+
+		// service calls of queued values must be known to skip found ones(ex.: data$... of the same service call added by 'enqueue')
+		queuedValues, err := a.dbModel.GetUnprocessedDbValues()
+		if err != nil {
+			return nil, fmt.Errorf("FAILURE: get list of unprocessed values in db(%s):\n\t%v", a.dsn, err)
+		}
+		a.fetchRequests(requests, queuedValues)
+
+		newServiceCalls, err := a.newServiceCalls(foundServiceCalls)
+		if err != nil {
+			return nil, fmt.Errorf("FAILURE: get service calls of values in db(%s):\n\t%v", a.dsn, err)
+		}
+
+		// already known service calls are skipped by db UNIQUE constraint
+		addedServiceCalls, _, err := a.dbModel.AddDbValues(newServiceCalls)
+		if err != nil {
+			return nil, fmt.Errorf("FAILURE: add found Naumen service calls to db(%s):\n\t%v", a.dsn, err)
+		}
+		a.logger.Info("finished finding new Naumen service calls", slog.Any("FOUND", len(foundServiceCalls)), slog.Any("ADDED", addedServiceCalls))
+	}
+
+	// getting list of unporcessed values in db
+	unprocessedValues, err := a.dbModel.GetUnprocessedDbValues()
+	if err != nil {
+		return nil, fmt.Errorf("FAILURE: get list of unprocessed values in db(%s):\n\t%v", a.dsn, err)
+	}
+
+	if len(unprocessedValues) == 0 {
+		return nil, errNoValues
+	}
+	a.logger.Info("current unprocessed Naumen data ids", slog.Any("LIST", unprocessedValues))
+
+	a.fetchRequests(requests, unprocessedValues)
+
+	// service calls of db values, to skip service call which is queued by other value too
+	serviceCalls, err := a.dbModel.GetDbValuesServiceCalls()
+	if err != nil {
+		return nil, fmt.Errorf("FAILURE: get service calls of values in db(%s):\n\t%v", a.dsn, err)
+	}
+
+	// loop to get all users & dates by DB unprocessedValues
+	// TODO: consider goroutine
+	for _, taskId := range unprocessedValues {
+		request := requests[taskId]
+		if request == nil {
+			continue
+		}
+
+		// ex.: serviceCall$... found by discovery, when data$... of it was added by 'enqueue' before
+		if other, ok := duplicateOf(*request, serviceCalls); ok {
+			a.logger.Warn("service call is queued by other db value, skipping it", "VAL", taskId, "OTHER", other)
+			if err := a.dbModel.UpdDbValue(taskId, 1); err != nil {
+				a.logger.Warn("failed to mark db value as processed", "VAL", taskId, slog.Any("ERR", err))
+			}
+			continue
+		}
+
+		sumDescriptionFound := fmt.Sprintf("found sumDescription of %s(%s):\n\t%v\n", request.RP, request.ServiceCall, request.Text)
+		a.logger.Info(sumDescriptionFound)
+
+		// sumDescription example:
+		// "sumDescription": "<font color=\"#5f5f5f\">Укажите учетную запись: <b>MAMYRBDA, MARCHENM</b>
+		// 	</font><br><font color=\"#5f5f5f\">Укажите дату:: <b>31.07.2025 00:59 - 31.07.2025 19:00</b></font><br>",
+
+		// parse sumDescription for users & dates using form templates
+		// broken ticket is marked as failed(may be requeued after fix), other tickets are processed as usual
+		parsed, err := sumparser.Parse(taskId, request.Text, a.naumenData.NaumenTemplates)
+		if err != nil {
+			a.failDbValue(taskId, request.ServiceCall, fmt.Sprintf("FAILURE: parse sumDescription of %s:\n\t%v", taskId, err))
+			continue
+		}
+		a.logger.Info("parsed sumDescription", "VAL", taskId, "TEMPLATE", parsed.Template, slog.Any("USERS", parsed.Users), slog.Any("DATES", parsed.Dates), slog.Any("USER DATES", parsed.UserDates))
+
+		// forming users: own dates of user or common dates of ticket
+		// ticket with wrong period of any user is marked as failed
+		var (
+			ticketUsers []User
+			periodErr   error
+		)
+		for _, foundUser := range parsed.Users {
+			dates := parsed.Dates
+			if userDates, ok := parsed.UserDates[foundUser]; ok {
+				dates = userDates
+			}
+
+			// parse period of user & format dates to FAZ format('00:00:01 2024/08/06') in FAZ time zone
+			reportPeriod, err := a.periodParser().Parse(dates...)
+			if err != nil {
+				periodErr = fmt.Errorf("user %s: %v", foundUser, err)
+				break
+			}
+
+			user.Username = strings.ToUpper(strings.Trim(foundUser, " "))
+			user.Period = reportPeriod
+			user.StartDate, user.EndDate = reportPeriod.In(a.fazLocation).Faz()
+			user.RP = request.RP
+			user.DBId = taskId
+			user.ServiceCall = request.ServiceCall
+
+			ticketUsers = append(ticketUsers, user)
+		}
+		if periodErr != nil {
+			a.failDbValue(taskId, request.ServiceCall, fmt.Sprintf("FAILURE: parse period of %s:\n\t%v", taskId, periodErr))
+			continue
+		}
+
+		// append formed users to users list
+		users = append(users, ticketUsers...)
+
+		// fill up Naumen tickets: several tickets(RP) may belong to one service call
+		tickets.add(taskId, request.ServiceCall, request.RP)
+		for _, ticketUser := range ticketUsers {
+			tickets.addUser(taskId, ticketUser.Username)
+		}
+	}
+
+	// all tickets are failed
+	if len(users) == 0 {
+		return nil, errNoValues
+	}
+
+	return users, nil
+}
+
+// fetch requests of db values which aren't fetched yet and save their service calls to db
+//
+// helpdesk may be unavailable for a while: value which can't be fetched stays unprocessed and is retried on next run
+func (a *app) fetchRequests(requests map[string]*ticketing.Request, values []string) {
+	for _, value := range values {
+		if _, ok := requests[value]; ok {
+			continue
+		}
+
+		request, err := a.helpdesk.Fetch(value)
+		if err != nil {
+			a.reportError(fmt.Sprintf("FAILURE: get getData from Naumen for '%s', it's retried on next run:\n\t%v", value, err))
+			requests[value] = nil
+			continue
+		}
+		requests[value] = &request
+
+		if err := a.dbModel.SetDbValueServiceCall(value, request.ServiceCall); err != nil {
+			a.logger.Warn("failed to save service call of db value", "VAL", value, slog.Any("ERR", err))
+		}
+	}
+}
+
+// found service calls which aren't service calls of db values yet
+func (a *app) newServiceCalls(found []string) ([]string, error) {
+	serviceCalls, err := a.dbModel.GetDbValuesServiceCalls()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(serviceCalls))
+	for _, serviceCall := range serviceCalls {
+		known[serviceCall] = true
+	}
+
+	result := make([]string, 0, len(found))
+	for _, serviceCall := range found {
+		if !known[serviceCall] {
+			result = append(result, serviceCall)
+		}
+	}
+
+	return result, nil
+}
+
+// other db value of service call, if request is the service call itself(serviceCalls - service calls of db values)
+func duplicateOf(request ticketing.Request, serviceCalls map[string]string) (string, bool) {
+	if request.ID != request.ServiceCall {
+		return "", false
+	}
+
+	for value, serviceCall := range serviceCalls {
+		if value != request.ID && serviceCall == request.ServiceCall {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// report error of db value(Naumen ticket) and mark it as failed('Processed' = 0)
+//
+// only for errors which repeat on retry(wrong form, period or users): failed value is processed again only after 'requeue';
+// ticket(service call) is commented or rejected with error by 'ticketing.on-failure'
+func (a *app) failDbValue(taskId, serviceCall, msg string) {
+	a.reportError(msg)
+
+	if err := a.dbModel.UpdDbValue(taskId, 0); err != nil {
+		a.logger.Warn("failed to mark db value as failed", "VAL", taskId, slog.Any("ERR", err))
+	}
+
+	var err error
+	switch a.naumenData.Ticketing.OnFailure {
+	case ticketFailureComment:
+		err = a.helpdesk.Comment(serviceCall, secrets.Redact(msg))
+	case ticketFailureReject:
+		err = a.helpdesk.Reject(serviceCall, secrets.Redact(msg))
+	default:
+		return
+	}
+	if err != nil {
+		a.logger.Warn("failed to "+a.naumenData.Ticketing.OnFailure+" failed ticket", "VAL", taskId, "SC", serviceCall, slog.Any("ERR", err))
+	}
+}
+
+// get FAZ reports of all users one by one and save them to reports dir
+//
+// period of user is split into slices(see '-split-period'), every slice is a separate FAZ report;
+// fill up Naumen tickets with downloaded reports file pathes(mode 'naumen')
+func (a *app) getReports(ctx context.Context, users []User, tickets *naumenTickets) error {
+	fazModel := a.fazModel
+
+	// GETTING FAZ SESSION ID(API token is sent with every request, no session is needed)
+	var sessionid string
+	if fazModel.ApiToken != "" {
+		a.logger.Info("using FAZ API token, skipping session login")
+	} else {
+		a.logger.Info("getting FAZ session id")
+		var errS error
+		sessionid, errS = fazModel.GetSessionid(a.fazClient, fazModel.FazUrl, fazModel.ApiUser, fazModel.ApiUserPass)
+		if errS != nil {
+			return fmt.Errorf("FAILURE: get FAZ sessionid\n\t%v", errS)
+		}
+		secrets.Register(sessionid)
+	}
+
+	// FAZ report layouts of profiles(faz-report-name or profile column of users.csv)
+	layouts := make(map[string]int)
+
+	// STARTING GETTING REPORT LOOP
+	a.logger.Info("Users data to process in FAZ:")
+	for _, user := range users {
+		a.logger.Info("processing now", slog.Any("USR", user))
+	}
+
+	// failure of one user skips only its ticket till next run(mode 'naumen'), other tickets are processed
+	failures := a.newUserFailures(tickets)
+
+	for _, user := range users {
+		if failures.failed(user) {
+			continue
+		}
+
+		reportFiles, jobIds, err := a.userReports(ctx, sessionid, layouts, user)
+		// files of failed user are registered too: they are purged on retry of ticket
+		a.outputsGenerated(user.DBId, reportFiles...)
+		if errors.Is(err, errInterrupted) {
+			return err
+		}
+		if err != nil {
+			if !a.naumenMode {
+				return err
+			}
+			// FAZ may be unavailable for a while, so ticket isn't marked as failed
+			failures.retry(user, "get FAZ report of "+user.Username, errors.New(strings.TrimPrefix(err.Error(), "FAILURE: ")))
+			continue
+		}
+
+		// fill up Naumen tickets with downloaded reports file pathes
+		if a.naumenMode {
+			tickets.addFiles(user.DBId, reportFiles...)
+		}
+
+		// mail reports to recipients of users.csv row
+		if len(user.Email) != 0 {
+			a.deliverEmail(user, reportFiles, jobIds)
+		}
+	}
+
+	return nil
+}
+
+// get FAZ reports of user(one per slice of period) & return their files and jobs
+//
+// files saved before error are returned with it
+func (a *app) userReports(ctx context.Context, sessionid string, layouts map[string]int, user User) ([]string, []int64, error) {
+	fazModel := a.fazModel
+
+	// GETTING FAZ REPORT LAYOUT
+	profile := a.reportProfile(user)
+	fazReportLayout, ok := layouts[profile]
+	if !ok {
+		var errLayout error
+		fazReportLayout, errLayout = fazModel.GetFazReportLayout(a.fazClient, fazModel.FazUrl, sessionid, fazModel.FazAdom, profile)
+		if errLayout != nil {
+			return nil, nil, fmt.Errorf("FAILURE: get FAZ report layout(%s):\n\t%v", profile, errLayout)
+		}
+		layouts[profile] = fazReportLayout
+	}
+
+	slices, err := user.Period.In(a.requesterLocation).Split(a.splitPeriod)
+	if err != nil {
+		return nil, nil, fmt.Errorf("FAILURE: split period of user(%s):\n\t%v", user.Username, err)
+	}
+
+	if len(slices) == 1 {
+		// don't start new report job on shutdown
+		if ctx.Err() != nil {
+			a.logger.Warn("shutdown requested, skipping remaining users", "USR", user.Username)
+			return nil, nil, errInterrupted
+		}
+
+		filePaths, err := a.getReport(sessionid, fazReportLayout, &user, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		return filePaths, []int64{user.JobId}, nil
+	}
+
+	a.logger.Info("period is split into slices", "USR", user.Username, "SPLIT", a.splitPeriod, "SLICES", len(slices))
+
+	var (
+		reportFiles []string
+		jobIds      []int64
+	)
+
+	manifest := reportManifest{
+		Username: user.Username,
+		Ticket:   user.DBId,
+		Start:    user.Period.In(a.requesterLocation).Start.Format(time.RFC3339),
+		End:      user.Period.In(a.requesterLocation).End.Format(time.RFC3339),
+		Split:    a.splitPeriod,
+	}
+
+	for _, slice := range slices {
+		if ctx.Err() != nil {
+			a.logger.Warn("shutdown requested, skipping remaining users", "USR", user.Username)
+			return reportFiles, jobIds, errInterrupted
+		}
+
+		sliceUser := user
+		sliceUser.Period = slice
+		sliceUser.StartDate, sliceUser.EndDate = slice.In(a.fazLocation).Faz()
+
+		filePaths, err := a.getReport(sessionid, fazReportLayout, &sliceUser, true)
+		if err != nil {
+			return reportFiles, jobIds, err
+		}
+
+		jobIds = append(jobIds, sliceUser.JobId)
+		for _, filePath := range filePaths {
+			manifest.Reports = append(manifest.Reports, manifestEntry{
+				File:     filepath.Base(filePath),
+				Start:    slice.Start.Format(time.RFC3339),
+				End:      slice.End.Format(time.RFC3339),
+				FazStart: sliceUser.StartDate,
+				FazEnd:   sliceUser.EndDate,
+			})
+		}
+		reportFiles = append(reportFiles, filePaths...)
+	}
+
+	packedFiles, err := a.packSlices(user, manifest, reportFiles)
+	if err != nil {
+		return reportFiles, jobIds, err
+	}
+
+	// merged archive replaces slice files
+	if a.mergeSlices {
+		for _, jobId := range jobIds {
+			if err := a.dbModel.SetJobOutputPath(jobId, packedFiles[0]); err != nil {
+				a.logger.Warn("failed to save report path of job", "USR", user.Username, slog.Any("ERR", err))
+			}
+		}
+	}
+
+	return packedFiles, jobIds, nil
+}
+
+// run one FAZ report of user's period, save it in every format of user to reports dir & return the paths
+//
+// job of report is saved to db, user.JobId is set
+func (a *app) getReport(sessionid string, fazReportLayout int, user *User, slice bool) ([]string, error) {
+	var err error
+
+	fazModel := a.fazModel
+	formats := user.reportFormats()
+
+	// file names are rendered before FAZ job(wrong template must not waste it)
+	reportFilePaths := make([]string, 0, len(formats))
+	for _, format := range formats {
+		reportFilePath, err := a.reportFilePath(*user, slice, format)
+		if err != nil {
+			return nil, err
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+
+	a.logger.Info("getting report job", "USR", user.Username, "START", user.StartDate, "END", user.EndDate)
+
+	// SAVING JOB TO DB
+	user.JobId, err = a.dbModel.AddJob(models.Job{
+		Ticket:      user.DBId,
+		ServiceCall: user.ServiceCall,
+		RP:          user.RP,
+		Username:    user.Username,
+		PeriodStart: user.StartDate,
+		PeriodEnd:   user.EndDate,
+		Status:      models.JobRunning,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("FAILURE: save job to db(%s):\n\t%v", user.Username, err)
+	}
+
+	// UPDATING DATASETS QUERY
+	errUpdDataset := fazModel.UpdateDatasetsVars(a.fazClient, fazModel.FazUrl, sessionid, fazModel.FazAdom, user.datasetVars(), fazModel.FazDatasets)
+	if errUpdDataset != nil {
+		return nil, a.failJob(*user, fmt.Sprintf("FAILURE: to update FAZ datasets:\n\t%v", errUpdDataset))
+	}
+
+	// STARTING REPORT
+	a.logger.Info("started running FAZ report job", "USR", user.Username, "PROFILE", a.reportProfile(*user))
+
+	repId, err := fazModel.StartReport(a.fazClient, fazModel.FazUrl, fazModel.FazAdom, a.reportDevice(*user), sessionid, user.StartDate, user.EndDate, fazReportLayout)
+	if err != nil {
+		return nil, a.failJob(*user, fmt.Sprintf("FAILURE: to start FAZ report:\n\t%v", err))
+	}
+
+	if err := a.dbModel.SetJobFazTid(user.JobId, repId); err != nil {
+		a.logger.Warn("failed to save FAZ report tid of job", "USR", user.Username, slog.Any("ERR", err))
+	}
+
+	for ind, format := range formats {
+		reportFilePath := reportFilePaths[ind]
+
+		// DOWNLOADING REPORT
+		a.logger.Info("started downloading report", "USR", user.Username, "FORMAT", format)
+
+		repData, err := fazModel.DownloadReport(a.fazClient, fazModel.FazUrl, fazModel.FazAdom, sessionid, repId, format)
+		if err != nil {
+			return nil, a.failJob(*user, fmt.Sprintf("FAILURE: dowonload FAZ report(%s):\n\t%v", format, err))
+		}
+
+		// SAVING REPORT TO FILE
+
+		// decoding base64 data to []byte
+		dec, err := base64.StdEncoding.DecodeString(repData)
+		if err != nil {
+			return nil, fmt.Errorf("FAILURE: to Decode Report Data(%s):\n\t%v", repData, err)
+		}
+
+		// creating report dir(ex.: 'Reports/RP***' for mode 'naumen')
+		if err := os.MkdirAll(filepath.Dir(reportFilePath), os.ModePerm); err != nil {
+			return nil, fmt.Errorf("FAILURE: create reports dir(%s):\n\t%v", filepath.Dir(reportFilePath), err)
+		}
+
+		if err := writeReportFile(reportFilePath, dec); err != nil {
+			return nil, err
+		}
+
+		if err := a.dbModel.SetJobOutputPath(user.JobId, reportFilePath); err != nil {
+			a.logger.Warn("failed to save report path of job", "USR", user.Username, slog.Any("ERR", err))
+		}
+	}
+
+	a.logger.Info("finished getting report job", "USR", user.Username, "RP", user.RP)
+
+	return reportFilePaths, nil
+}
+
+// mail report files of user to recipients of users.csv row
+//
+// failure is reported, files stay not delivered(they are not purged)
+func (a *app) deliverEmail(user User, files []string, jobIds []int64) {
+	requesterPeriod := user.Period.In(a.requesterLocation)
+	msg := fmt.Sprintf("Reports of %s(%s - %s) are attached.\n",
+		user.Username, requesterPeriod.Start.Format("02.01.2006 15:04:05"), requesterPeriod.End.Format("02.01.2006 15:04:05"))
+
+	mailData, err := a.loadMailing()
+	if err == nil {
+		err = mailer.SendFiles(*mailData, user.Email, appName, []byte(msg), files)
+	}
+	if err != nil {
+		a.reportError(fmt.Sprintf("FAILURE: mail reports of %s to %v:\n\t%v", user.Username, user.Email, err))
+		return
+	}
+
+	a.outputsDelivered(files)
+	for _, jobId := range jobIds {
+		if err := a.dbModel.SetJobStatus(jobId, models.JobDelivered, ""); err != nil {
+			a.logger.Warn("failed to update job status", "USR", user.Username, slog.Any("ERR", err))
+		}
+	}
+
+	a.logger.Info("mailed reports", "USR", user.Username, slog.Any("EMAIL", user.Email), slog.Any("FILES", files))
+}
+
+// mark job of user as failed and return error with msg
+func (a *app) failJob(user User, msg string) error {
+	if errJob := a.dbModel.SetJobStatus(user.JobId, models.JobFailed, msg); errJob != nil {
+		a.logger.Warn("failed to update job status", slog.Any("ERR", errJob))
+	}
+	return errors.New(msg)
+}
+
+// create report file(full path) and write decoded report data to it
+func writeReportFile(reportFilePath string, data []byte) error {
+	file, err := os.Create(reportFilePath)
+	if err != nil {
+		return fmt.Errorf("FAILURE: to Create Report Blank File(%s):\n\t%v", reportFilePath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("FAILURE: to Write Report Data to File(%s):\n\t%v", reportFilePath, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("FAILURE: to Sync Written Report File(%s):\n\t%v", reportFilePath, err)
+	}
+
+	return nil
+}
+
+// take responsibility on Naumen requests, attach files, set acceptance and mark db values as processed
+//
+// files of all tickets of service call are attached at once
+func (a *app) deliverNaumen(tickets *naumenTickets) error {
+	a.logger.Info("Collected task data for Naumen RPs:")
+	for _, sc := range tickets.ServiceCalls() {
+		for _, ticket := range tickets.Tickets(sc) {
+			a.logger.Info("-", "SC", sc, "RP", ticket.RP, "VAL", ticket.DBId, slog.Any("USERS", ticket.Users), slog.Any("FILES", ticket.Files))
+		}
+	}
+
+	// take responsibility on request, attach files and set acceptance
+	for _, sc := range tickets.ServiceCalls() {
+		var (
+			files []string
+			rps   []string
+		)
+		for _, ticket := range tickets.Tickets(sc) {
+			files = append(files, ticket.Files...)
+			rps = append(rps, ticket.RP)
+		}
+
+		if len(files) == 0 {
+			a.logger.Warn("no reports to attach to Naumen ticket, skipping", "SC", sc, slog.Any("RP", rps))
+			continue
+		}
+
+		// take responsibility on request
+		a.logger.Info("started take responsibility on Naumen ticket", "SC", sc)
+
+		// failed service call doesn't stop delivery to others
+		if errT := a.helpdesk.Claim(sc); errT != nil {
+			a.failServiceCall(tickets, sc, fmt.Sprintf("FAILURE: take responsibility on Naumen ticket(%s, %v):\n\t%v", sc, rps, errT))
+			continue
+		}
+
+		// attach files to service call and set acceptance
+		a.logger.Info("started attaching files to ticket and set acceptance", "SC", sc, slog.Any("RP", rps))
+
+		if errA := a.helpdesk.Attach(sc, files); errA != nil {
+			a.failServiceCall(tickets, sc, fmt.Sprintf("FAILURE: attaching files to ticket(%s, %v):\n\t%v", sc, rps, errA))
+			continue
+		}
+		if errR := a.helpdesk.Resolve(sc, a.solutionText); errR != nil {
+			a.failServiceCall(tickets, sc, fmt.Sprintf("FAILURE: attaching files to ticket and set acceptance(%s, %v):\n\t%v", sc, rps, errR))
+			continue
+		}
+
+		a.logger.Info("finished take responsibility, attach reports and set acceptance on Naumen ticket", "SC", sc, slog.Any("RP", rps))
+
+		for _, ticket := range tickets.Tickets(sc) {
+			a.outputsDelivered(ticket.Files)
+
+			a.logger.Info("started update db with success result", "VAL", ticket.DBId)
+
+			errU := a.dbModel.UpdDbValue(ticket.DBId, 1)
+			if errU != nil {
+				return fmt.Errorf("FAILURE: update value(%s) to result(%v):\n\t%v", ticket.DBId, 1, errU)
+			}
+
+			if err := a.dbModel.SetTicketJobsStatus(ticket.DBId, models.JobDelivered); err != nil {
+				a.logger.Warn("failed to update jobs status", "VAL", ticket.DBId, slog.Any("ERR", err))
+			}
+
+			// report success
+			a.reportSuccess(fmt.Sprintf("FINISHED: processing, including DBUpd: %s\n", ticket.RP))
+		}
+	}
+
+	return nil
+}
+
+// report failed delivery of service call, its tickets stay unprocessed and are retried on next run
+func (a *app) failServiceCall(tickets *naumenTickets, serviceCall, msg string) {
+	a.reportError(msg)
+
+	for _, ticket := range tickets.Tickets(serviceCall) {
+		if err := a.dbModel.SetTicketJobsStatus(ticket.DBId, models.JobFailed); err != nil {
+			a.logger.Warn("failed to update jobs status", "VAL", ticket.DBId, slog.Any("ERR", err))
+		}
+	}
+}
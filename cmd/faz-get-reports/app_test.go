@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	fazrep "github.com/slayerjk/faz-get-reports/internal/fazrequests"
+	"github.com/slayerjk/faz-get-reports/internal/helpers"
+	models "github.com/slayerjk/faz-get-reports/internal/models"
+	"github.com/slayerjk/faz-get-reports/internal/ticketing"
+)
+
+// helpdesk of tests: requests by id, unknown id is a fetch error; found ids are ids of Find; comments & rejects are kept
+type fakeHelpdesk struct {
+	requests map[string]ticketing.Request
+	found    []string
+	comments []string
+	rejects  []string
+}
+
+func (h *fakeHelpdesk) Find() ([]string, error) {
+	return h.found, nil
+}
+
+func (h *fakeHelpdesk) Fetch(id string) (ticketing.Request, error) {
+	request, ok := h.requests[id]
+	if !ok {
+		return ticketing.Request{}, errors.New("fetch: bad response status code: 503 Service Unavailable")
+	}
+	return request, nil
+}
+
+func (h *fakeHelpdesk) Claim(string) error            { return nil }
+func (h *fakeHelpdesk) Attach(string, []string) error { return nil }
+func (h *fakeHelpdesk) Resolve(string, string) error  { return nil }
+
+func (h *fakeHelpdesk) Comment(serviceCall, _ string) error {
+	h.comments = append(h.comments, serviceCall)
+	return nil
+}
+
+func (h *fakeHelpdesk) Reject(serviceCall, _ string) error {
+	h.rejects = append(h.rejects, serviceCall)
+	return nil
+}
+
+// app of mode 'naumen' with new db in temp dir, values are added to db
+func newTestApp(t *testing.T, helpdesk *fakeHelpdesk, values ...string) *app {
+	t.Helper()
+
+	dir := t.TempDir()
+	db, err := helpers.OpenDB(filepath.Join(dir, "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	dbModel, err := models.NewDbModel(db, dbDataTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbModel.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := dbModel.AddDbValues(values); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &app{
+		logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		dbModel:           dbModel,
+		mode:              "naumen",
+		naumenMode:        true,
+		resultsPath:       filepath.Join(dir, "Reports"),
+		requesterLocation: time.UTC,
+		fazLocation:       time.UTC,
+		fazModel:          &fazrep.FazModelJson{},
+		helpdesk:          helpdesk,
+	}
+	a.naumenData.Ticketing.OnFailure = ticketFailureComment
+
+	return a
+}
+
+func unprocessedValues(t *testing.T, a *app) []string {
+	t.Helper()
+
+	values, err := a.dbModel.GetUnprocessedDbValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestNaumenUsersFetchError(t *testing.T) {
+	// data$1 can't be fetched(helpdesk is unavailable), data$2 has wrong form
+	helpdesk := &fakeHelpdesk{requests: map[string]ticketing.Request{
+		"data$2": {ID: "data$2", ServiceCall: "serviceCall$2", RP: "RP2", Text: "no users"},
+	}}
+	a := newTestApp(t, helpdesk, "data$1", "data$2")
+
+	_, err := a.naumenUsers(newNaumenTickets())
+	if !errors.Is(err, errNoValues) {
+		t.Fatalf("naumenUsers() error = %v, want errNoValues", err)
+	}
+
+	// fetch error is retried on next run, wrong form is marked as failed
+	if got, want := unprocessedValues(t, a), []string{"data$1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unprocessed values = %v, want %v", got, want)
+	}
+	if want := []string{"serviceCall$2"}; !reflect.DeepEqual(helpdesk.comments, want) {
+		t.Errorf("commented tickets = %v, want %v", helpdesk.comments, want)
+	}
+}
+
+// not delivered report file of ticket(previous failed run)
+func addOutputFile(t *testing.T, a *app, ticket, name string) string {
+	t.Helper()
+
+	path := filepath.Join(a.resultsPath, name)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("report"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.dbModel.AddOutput(path, ticket); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunFetchErrorKeepsOutputs(t *testing.T) {
+	a := newTestApp(t, &fakeHelpdesk{}, "data$1")
+	previous := addOutputFile(t, a, "data$1", "RP1/USER1.pdf")
+
+	if err := a.run(context.Background()); !errors.Is(err, errNoValues) {
+		t.Fatalf("run() error = %v, want errNoValues", err)
+	}
+
+	// reports aren't generated again, so previous ones are the only copies
+	if _, err := os.Stat(previous); err != nil {
+		t.Errorf("previous report file: %v, want it kept", err)
+	}
+	outputs, err := a.dbModel.GetTicketOutputs("data$1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || outputs[0].Path != previous {
+		t.Errorf("not delivered outputs = %+v, want %s", outputs, previous)
+	}
+}
+
+func TestPurgePreviousOutputs(t *testing.T) {
+	a := newTestApp(t, &fakeHelpdesk{})
+	previous := addOutputFile(t, a, "data$1", "RP1/USER1.pdf")
+	current := addOutputFile(t, a, "data$1", "RP1/USER1_2.pdf")
+	// ticket isn't generated again in this run
+	other := addOutputFile(t, a, "data$2", "RP2/USER2.pdf")
+
+	tickets := newNaumenTickets()
+	tickets.add("data$1", "serviceCall$1", "RP1")
+	tickets.addFiles("data$1", current)
+
+	a.purgePreviousOutputs(tickets)
+
+	if _, err := os.Stat(previous); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("previous report file: %v, want it purged", err)
+	}
+	for _, path := range []string{current, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("report file: %v, want it kept", err)
+		}
+	}
+
+	outputs, err := a.dbModel.GetTicketOutputs("data$1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || outputs[0].Path != current {
+		t.Errorf("not delivered outputs = %+v, want %s", outputs, current)
+	}
+}
+
+func TestGetReportsFazError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	helpdesk := &fakeHelpdesk{}
+	a := newTestApp(t, helpdesk, "data$1")
+	a.fazClient = server.Client()
+	a.fazModel = &fazrep.FazModelJson{FazUrl: server.URL, ApiToken: "token", FazReportName: "report"}
+
+	tickets := newNaumenTickets()
+	tickets.add("data$1", "serviceCall$1", "RP1")
+	user := User{Username: "USER1", DBId: "data$1", ServiceCall: "serviceCall$1", RP: "RP1"}
+
+	if err := a.getReports(context.Background(), []User{user}, tickets); err != nil {
+		t.Fatalf("getReports() error: %v", err)
+	}
+
+	// FAZ outage doesn't fail ticket: it's not delivered now and is retried on next run
+	if got := tickets.ServiceCalls(); len(got) != 0 {
+		t.Errorf("service calls to deliver = %v, want none", got)
+	}
+	if got, want := unprocessedValues(t, a), []string{"data$1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unprocessed values = %v, want %v", got, want)
+	}
+	if len(helpdesk.comments) != 0 {
+		t.Errorf("commented tickets = %v, want none", helpdesk.comments)
+	}
+}
+
+// values of db in order of adding
+func dbValues(t *testing.T, a *app) []string {
+	t.Helper()
+
+	rows, err := a.dbModel.DB.Query(`SELECT "Value" FROM "Data" ORDER BY "ID"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// data$1 is RP of serviceCall$1, serviceCall$2 has no RP in db
+func duplicateHelpdesk() *fakeHelpdesk {
+	form := "Укажите учетную запись: USER1<br>Укажите дату: 01.07.2025 - 02.07.2025"
+	return &fakeHelpdesk{
+		requests: map[string]ticketing.Request{
+			"data$1":        {ID: "data$1", ServiceCall: "serviceCall$1", RP: "RP1", Text: form},
+			"serviceCall$1": {ID: "serviceCall$1", ServiceCall: "serviceCall$1", RP: "RP1", Text: form},
+			"serviceCall$2": {ID: "serviceCall$2", ServiceCall: "serviceCall$2", RP: "RP2", Text: form},
+		},
+		found: []string{"serviceCall$1", "serviceCall$2"},
+	}
+}
+
+func TestDiscoveryAfterEnqueue(t *testing.T) {
+	a := newTestApp(t, duplicateHelpdesk(), "data$1")
+	a.mode = "naumen-discovery"
+
+	tickets := newNaumenTickets()
+	if _, err := a.naumenUsers(tickets); err != nil {
+		t.Fatalf("naumenUsers() error: %v", err)
+	}
+
+	// service call of enqueued data$1 isn't added again
+	if got, want := dbValues(t, a), []string{"data$1", "serviceCall$2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("db values = %v, want %v", got, want)
+	}
+	if got, want := tickets.ServiceCalls(), []string{"serviceCall$1", "serviceCall$2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("service calls to deliver = %v, want %v", got, want)
+	}
+	if got := tickets.Tickets("serviceCall$1"); len(got) != 1 || got[0].DBId != "data$1" {
+		t.Errorf("tickets of serviceCall$1 = %+v, want data$1 only", got)
+	}
+}
+
+func TestEnqueueAfterDiscovery(t *testing.T) {
+	// service call found by previous discovery, then its RP is enqueued
+	a := newTestApp(t, duplicateHelpdesk(), "serviceCall$1", "data$1")
+
+	tickets := newNaumenTickets()
+	users, err := a.naumenUsers(tickets)
+	if err != nil {
+		t.Fatalf("naumenUsers() error: %v", err)
+	}
+
+	// service call is processed once, by data$1
+	if len(users) != 1 || users[0].DBId != "data$1" {
+		t.Errorf("users = %+v, want one user of data$1", users)
+	}
+	if got := tickets.Tickets("serviceCall$1"); len(got) != 1 || got[0].DBId != "data$1" {
+		t.Errorf("tickets of serviceCall$1 = %+v, want data$1 only", got)
+	}
+	if got, want := unprocessedValues(t, a), []string{"data$1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unprocessed values = %v, want %v", got, want)
+	}
+}
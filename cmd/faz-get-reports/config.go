@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	fazrep "github.com/slayerjk/faz-get-reports/internal/fazrequests"
+	"github.com/slayerjk/faz-get-reports/internal/ldapresolver"
+	"github.com/slayerjk/faz-get-reports/internal/mailer"
+	"github.com/slayerjk/faz-get-reports/internal/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+// version of unified config file format
+const configVersion = 1
+
+// names of unified config file in config dir, the first existing one is used
+var configFileNames = []string{"config.yaml", "config.yml", "config.json"}
+
+// sections of unified config file(integrations), same keys as legacy data files
+const (
+	sectionFaz     = "faz"
+	sectionNaumen  = "naumen"
+	sectionLdap    = "ldap"
+	sectionMailing = "mailing"
+	// flags, ex.: 'mode: csv'
+	sectionOptions = "options"
+)
+
+// env var names of flags which don't follow '<envPrefix><FLAG NAME>' rule
+var flagEnvNames = map[string]string{
+	"m": "MAILING",
+	// KEYSTORE_KEY is keystore key itself
+	"keystore-key": "KEYSTORE_KEY_FILE",
+}
+
+// keys of credentials, masked by 'config check'(secret references are shown as is)
+var secretKeys = map[string]bool{
+	"api-user-pass":     true,
+	"api-token":         true,
+	"naumen-access-key": true,
+	"ldap-bind-pass":    true,
+	"auth_pass":         true,
+	// header of REST helpdesk API(naumen-data.json "ticketing.rest")
+	"Authorization": true,
+}
+
+// sources of settings: unified config file & legacy data files of sections missing in it
+type configSource struct {
+	// unified config file, empty if there is none
+	ConfigFile     string
+	FazDataFile    string
+	NaumenDataFile string
+	LdapDataFile   string
+	MailingFile    string
+}
+
+// loaded sections, nil if section isn't required and there is no data of it
+type configSections struct {
+	Faz     *fazrep.FazModelJson `json:"faz,omitempty"`
+	Naumen  *naumenData          `json:"naumen,omitempty"`
+	Ldap    *ldapresolver.Config `json:"ldap,omitempty"`
+	Mailing *mailer.MailData     `json:"mailing,omitempty"`
+	// file of every loaded section
+	Sources map[string]string `json:"-"`
+}
+
+// path of unified config file: explicit one or the first existing one in config dir("" if there is none)
+func findConfigFile(configFile, configDir string) string {
+	if configFile != "" {
+		return configFile
+	}
+
+	for _, name := range configFileNames {
+		path := filepath.Join(configDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// read unified config file(JSON by '.json' extension, YAML otherwise): version & unknown keys are checked
+func readConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file(%s):\n\t%v", path, err)
+	}
+
+	raw := make(map[string]any)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file(%s):\n\t%v", path, err)
+	}
+
+	version, ok := raw["version"]
+	if !ok {
+		return nil, fmt.Errorf("config file(%s): 'version' is not set(current version is %d)", path, configVersion)
+	}
+	if fmt.Sprint(version) != strconv.Itoa(configVersion) {
+		return nil, fmt.Errorf("config file(%s): unsupported version %v(current version is %d)", path, version, configVersion)
+	}
+
+	for key, value := range raw {
+		switch key {
+		case "version":
+			continue
+		case sectionOptions, sectionFaz, sectionNaumen, sectionLdap, sectionMailing:
+			if _, ok := value.(map[string]any); !ok {
+				return nil, fmt.Errorf("config file(%s): section '%s' must be a map", path, key)
+			}
+		default:
+			return nil, fmt.Errorf("config file(%s): unknown key '%s'", path, key)
+		}
+	}
+
+	return raw, nil
+}
+
+// load all sections, required ones must exist in unified config file or in legacy data files
+//
+// env vars '<envPrefix><SECTION>__<KEY>'(ex.: FAZ_GET_REPORTS_FAZ__API_TOKEN) override top-level keys of sections
+func (s configSource) load(required ...string) (*configSections, error) {
+	return s.loadSections(nil, required)
+}
+
+// load only mailing section(errors of other sections must not prevent mailing of them)
+func (s configSource) loadMailing() (*mailer.MailData, error) {
+	sections, err := s.loadSections([]string{sectionMailing}, []string{sectionMailing})
+	if err != nil {
+		return nil, err
+	}
+	return sections.Mailing, nil
+}
+
+// load sections of names(all if nil), see load
+func (s configSource) loadSections(names, required []string) (*configSections, error) {
+	unified := make(map[string]any)
+	if s.ConfigFile != "" {
+		raw, err := readConfigFile(s.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		unified = raw
+	}
+
+	contains := func(list []string, name string) bool {
+		for _, item := range list {
+			if item == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	sections := &configSections{Sources: make(map[string]string)}
+
+	var (
+		faz      fazrep.FazModelJson
+		naumen   naumenData
+		ldap     ldapresolver.Config
+		mailData mailer.MailData
+	)
+	targets := []struct {
+		name       string
+		legacyFile string
+		target     any
+		assign     func()
+	}{
+		{sectionFaz, s.FazDataFile, &faz, func() { sections.Faz = &faz }},
+		{sectionNaumen, s.NaumenDataFile, &naumen, func() { sections.Naumen = &naumen }},
+		{sectionLdap, s.LdapDataFile, &ldap, func() { sections.Ldap = &ldap }},
+		{sectionMailing, s.MailingFile, &mailData, func() { sections.Mailing = &mailData }},
+	}
+
+	for _, item := range targets {
+		if names != nil && !contains(names, item.name) {
+			continue
+		}
+
+		if raw, ok := unified[item.name]; ok {
+			if err := decodeSection(item.name, raw.(map[string]any), item.target, true); err != nil {
+				return nil, fmt.Errorf("config file(%s): %v", s.ConfigFile, err)
+			}
+			sections.Sources[item.name] = s.ConfigFile
+			item.assign()
+			continue
+		}
+
+		// legacy data file: unknown keys are allowed as before
+		data, err := os.ReadFile(item.legacyFile)
+		if errors.Is(err, fs.ErrNotExist) && !contains(required, item.name) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s data file(no '%s' section in config file):\n\t%v", item.name, item.name, err)
+		}
+		raw := make(map[string]any)
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s data file(%s):\n\t%v", item.name, item.legacyFile, err)
+		}
+		if err := decodeSection(item.name, raw, item.target, false); err != nil {
+			return nil, fmt.Errorf("%s data file(%s): %v", item.name, item.legacyFile, err)
+		}
+		sections.Sources[item.name] = item.legacyFile
+		item.assign()
+	}
+
+	return sections, nil
+}
+
+// decode section map into struct(by its json tags) with env overrides, unknown keys are errors if strict
+func decodeSection(name string, raw map[string]any, target any, strict bool) error {
+	if err := applyEnvOverrides(name, raw, target); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("section '%s':\n\t%v", name, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("section '%s':\n\t%v", name, err)
+	}
+
+	return nil
+}
+
+// set top-level keys of section from env vars '<envPrefix><SECTION>__<KEY>'('-' of key is '_'),
+// scalars & string lists(comma separated) are taken as is, other values must be JSON
+func applyEnvOverrides(name string, raw map[string]any, target any) error {
+	targetType := reflect.TypeOf(target).Elem()
+
+	for ind := 0; ind < targetType.NumField(); ind++ {
+		field := targetType.Field(ind)
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+
+		envName := envPrefix + strings.ToUpper(name+"__"+strings.ReplaceAll(key, "-", "_"))
+		value, ok := os.LookupEnv(envName)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case field.Type.Kind() == reflect.String:
+			raw[key] = value
+		case field.Type.Kind() == reflect.Bool:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("env %s: %v", envName, err)
+			}
+			raw[key] = parsed
+		case field.Type.Kind() == reflect.Int:
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("env %s: %v", envName, err)
+			}
+			raw[key] = parsed
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String && !strings.HasPrefix(value, "["):
+			raw[key] = strings.Split(value, ",")
+		default:
+			var parsed any
+			if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+				return fmt.Errorf("env %s: value must be JSON:\n\t%v", envName, err)
+			}
+			raw[key] = parsed
+		}
+	}
+
+	return nil
+}
+
+// env var name of flag
+func flagEnvName(name string) string {
+	if envName, ok := flagEnvNames[name]; ok {
+		return envPrefix + envName
+	}
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// set flags which aren't set explicitly from env vars
+//
+// returns names of flags set explicitly or by env
+func applyFlagEnv(flags *flag.FlagSet) (map[string]bool, error) {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var errs []error
+	flags.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] {
+			return
+		}
+		value, ok := os.LookupEnv(flagEnvName(f.Name))
+		if !ok || value == "" {
+			return
+		}
+		if err := flags.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %v", flagEnvName(f.Name), err))
+			return
+		}
+		set[f.Name] = true
+	})
+
+	return set, errors.Join(errs...)
+}
+
+// set flags which aren't set explicitly or by env from 'options' section of config file, unknown options are errors
+func applyFlagOptions(flags *flag.FlagSet, options map[string]any, set map[string]bool) error {
+	var errs []error
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if flags.Lookup(name) == nil || name == "config" {
+			errs = append(errs, fmt.Errorf("unknown option '%s'", name))
+			continue
+		}
+		if set[name] {
+			continue
+		}
+
+		value := options[name]
+		switch value.(type) {
+		case map[string]any, []any:
+			errs = append(errs, fmt.Errorf("option '%s' must be a scalar", name))
+			continue
+		}
+		if err := flags.Set(name, fmt.Sprint(value)); err != nil {
+			errs = append(errs, fmt.Errorf("option '%s': %v", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// subcommand 'config check': print effective config(flags & sections) with masked secrets
+//
+// config is checked as by run(required sections, secret references, TLS & templates), exit code 1 on errors
+func configCheck(a *app, flags *flag.FlagSet) int {
+	sections, err := a.config.load(a.requiredSections()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config is wrong:\n\t%v\n", err)
+		return 1
+	}
+
+	options := make(map[string]any)
+	flags.VisitAll(func(f *flag.Flag) {
+		// bools & numbers as is, durations as strings('5m0s')
+		switch value := f.Value.(flag.Getter).Get().(type) {
+		case bool, int:
+			options[f.Name] = value
+		default:
+			options[f.Name] = f.Value.String()
+		}
+	})
+
+	// effective network settings
+	if sections.Faz != nil {
+		sections.Faz.FazNetwork = sections.Faz.FazNetwork.WithDefaults()
+	}
+	if sections.Naumen != nil {
+		sections.Naumen.NaumenNetwork = sections.Naumen.NaumenNetwork.WithDefaults()
+	}
+	if sections.Mailing != nil {
+		sections.Mailing.Network = sections.Mailing.Network.WithDefaults()
+	}
+
+	effective := map[string]any{
+		"version":      configVersion,
+		sectionOptions: options,
+	}
+
+	sectionsData, err := json.Marshal(sections)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to marshal config:\n\t%v\n", err)
+		return 1
+	}
+	sectionsMap := make(map[string]any)
+	if err := json.Unmarshal(sectionsData, &sectionsMap); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to unmarshal config:\n\t%v\n", err)
+		return 1
+	}
+	for name, section := range sectionsMap {
+		effective[name] = maskSecrets(section)
+	}
+
+	output, err := yaml.Marshal(effective)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to marshal config:\n\t%v\n", err)
+		return 1
+	}
+
+	if a.config.ConfigFile != "" {
+		fmt.Fprintf(os.Stdout, "# config file: %s\n", a.config.ConfigFile)
+	}
+	for _, name := range []string{sectionFaz, sectionNaumen, sectionLdap, sectionMailing} {
+		if source, ok := sections.Sources[name]; ok {
+			fmt.Fprintf(os.Stdout, "# %s: %s\n", name, source)
+		}
+	}
+	fmt.Fprint(os.Stdout, string(output))
+
+	// the same checks as on start of run
+	if err := a.loadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "config is wrong:\n\t%v\n", err)
+		return 1
+	}
+
+	fmt.Fprintln(os.Stderr, "config is OK")
+
+	return 0
+}
+
+// copy of value with secrets(by key) replaced by '***', secret references('env:', 'file:', 'keystore:') are kept
+func maskSecrets(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for key, item := range typed {
+			text, isText := item.(string)
+			switch {
+			case secretKeys[key] && isText && text != "" && !isSecretRef(text):
+				result[key] = "***"
+			case key == "proxy" && isText:
+				result[key] = redactURL(text)
+			default:
+				result[key] = maskSecrets(item)
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, 0, len(typed))
+		for _, item := range typed {
+			result = append(result, maskSecrets(item))
+		}
+		return result
+	}
+
+	return value
+}
+
+// value is secret reference(resolved on load)
+func isSecretRef(value string) bool {
+	return strings.HasPrefix(value, secrets.PrefixEnv) || strings.HasPrefix(value, secrets.PrefixFile) || strings.HasPrefix(value, secrets.PrefixKeystore)
+}
+
+// URL with password replaced by 'xxxxx'(ex.: proxy URL)
+func redactURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	return parsed.Redacted()
+}
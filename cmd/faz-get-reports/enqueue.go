@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/slayerjk/faz-get-reports/internal/helpers"
+	models "github.com/slayerjk/faz-get-reports/internal/models"
+)
+
+// subcommand 'enqueue': add Naumen task ids(data$...) to db to process in mode 'naumen'
+func enqueueCmd(args []string, dbFile string) int {
+	return dbValuesCmd("enqueue", args, dbFile, func(dbModel *models.DbModel, values []string) error {
+		added, duplicates, err := dbModel.AddDbValues(values)
+		for _, value := range added {
+			fmt.Fprintf(os.Stdout, "added: %s\n", value)
+		}
+		for _, value := range duplicates {
+			fmt.Fprintf(os.Stdout, "duplicate(already in db): %s\n", value)
+		}
+		return err
+	})
+}
+
+// subcommand 'requeue': reset processed state of Naumen task ids to process them again
+func requeueCmd(args []string, dbFile string) int {
+	return dbValuesCmd("requeue", args, dbFile, func(dbModel *models.DbModel, values []string) error {
+		reset, notFound, err := dbModel.ResetDbValues(values)
+		for _, value := range reset {
+			fmt.Fprintf(os.Stdout, "requeued: %s\n", value)
+		}
+		for _, value := range notFound {
+			fmt.Fprintf(os.Stdout, "not found in db: %s\n", value)
+		}
+		return err
+	})
+}
+
+// common part of enqueue/requeue subcommands: parse flags, read values, open db and run action
+func dbValuesCmd(name string, args []string, dbFile string, action func(*models.DbModel, []string) error) int {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	dsn := flags.String("dsn", dbFile, "SQLITE3 db file full path")
+	valuesFile := flags.String("f", "", "file with Naumen task ids(one per line); '-' for stdin")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags] [data$ID ...]\n", appName, name)
+		fmt.Fprintln(flags.Output(), "Task ids are read from args, '-f' file or stdin(if neither is set).")
+		fmt.Fprintln(flags.Output(), "Flags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	// collect values from args, file or stdin
+	values := make([]string, 0)
+	values = append(values, flags.Args()...)
+
+	switch {
+	case *valuesFile == "-" || (*valuesFile == "" && len(values) == 0):
+		fileValues, err := readValues(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read task ids from stdin:\n\t%v\n", err)
+			return 1
+		}
+		values = append(values, fileValues...)
+	case *valuesFile != "":
+		file, err := os.Open(*valuesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open task ids file:\n\t%v\n", err)
+			return 1
+		}
+		defer file.Close()
+
+		fileValues, err := readValues(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read task ids file(%s):\n\t%v\n", *valuesFile, err)
+			return 1
+		}
+		values = append(values, fileValues...)
+	}
+
+	if len(values) == 0 {
+		fmt.Fprintln(os.Stderr, "no task ids to process")
+		return 1
+	}
+
+	// open db & apply migrations(db may be new)
+	if err := os.MkdirAll(filepath.Dir(*dsn), os.ModePerm); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create db dir(%s):\n\t%v\n", *dsn, err)
+		return 1
+	}
+
+	db, err := helpers.OpenDB(*dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open DB file(%s):\n\t%v\n", *dsn, err)
+		return 1
+	}
+	defer db.Close()
+
+	dbModel, err := models.NewDbModel(db, dbDataTable)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to define db model:\n\t%v\n", err)
+		return 1
+	}
+
+	if _, err := dbModel.Migrate(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply db migrations(%s):\n\t%v\n", *dsn, err)
+		return 1
+	}
+
+	if err := action(dbModel, values); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s task ids:\n\t%v\n", name, err)
+		return 1
+	}
+
+	return 0
+}
+
+// read values: one per line, blank lines and lines starting with '#' are skipped
+func readValues(r io.Reader) ([]string, error) {
+	result := make([]string, 0)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result = append(result, line)
+	}
+
+	return result, scanner.Err()
+}
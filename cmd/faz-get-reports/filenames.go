@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// default report file templates(relative to reports dir)
+const (
+	csvFileTemplate    = "{{.ShortName}}_{{.Start}}_{{.End}}.{{.Format}}"
+	naumenFileTemplate = "{{.RP}}/{{.User}}{{if .Slice}}_{{.Start}}_{{.End}}{{end}}.{{.Format}}"
+)
+
+// format of period in file names
+const fileDateLayout = "02-01-2006-T-15-04-05"
+
+// unsafe characters of file names: path separators, reserved by Windows & control ones
+var reUnsafeName = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+
+// report file template variables
+type fileNameData struct {
+	User        string
+	DisplayName string
+	// 'Surname N.P.'
+	ShortName string
+	// AD group of user(empty if user is not from group entry)
+	Group  string
+	RP     string
+	SC     string
+	Ticket string
+	// period in requester time zone, format: DD-MM-YYYY-T-hh-mm-ss
+	Start   string
+	End     string
+	Profile string
+	// file format of FAZ data(zip)
+	Format string
+	// report format in lower case: pdf, html, xml, csv, json
+	Type  string
+	RunID string
+	// report of period slice(see '-split-period')
+	Slice bool
+	// extra vars of users.csv row('var:<NAME>' columns)
+	Vars map[string]string
+}
+
+// parse report file template(default one of mode if empty) and check it with sample data
+func parseFileTemplate(text string, naumenMode bool) (*template.Template, error) {
+	if text == "" {
+		text = csvFileTemplate
+		if naumenMode {
+			text = naumenFileTemplate
+		}
+	}
+
+	fileTemplate, err := template.New("file").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file template(%s):\n\t%v", text, err)
+	}
+
+	// vars of users.csv rows are unknown here, they are checked with every row
+	sampleTemplate, err := fileTemplate.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check file template(%s):\n\t%v", text, err)
+	}
+	sampleTemplate.Option("missingkey=zero")
+
+	sample := fileNameData{User: "USER", DisplayName: "User User", ShortName: "User U.", Group: "Group", RP: "RP1", SC: "serviceCall$1", Ticket: "data$1", Start: "01-01-2025-T-00-00-00", End: "01-01-2025-T-23-59-59", Profile: "report", Format: "zip", Type: "pdf", RunID: "1"}
+	if _, err := renderFileName(sampleTemplate, sample); err != nil {
+		return nil, fmt.Errorf("failed to check file template(%s):\n\t%v", text, err)
+	}
+
+	return fileTemplate, nil
+}
+
+// render relative report file path: unsafe characters of variables are replaced, path must stay inside reports dir
+func renderFileName(fileTemplate *template.Template, data fileNameData) (string, error) {
+	data.User = reUnsafeName.ReplaceAllString(data.User, "_")
+	data.DisplayName = reUnsafeName.ReplaceAllString(data.DisplayName, "_")
+	data.ShortName = reUnsafeName.ReplaceAllString(data.ShortName, "_")
+	data.Group = reUnsafeName.ReplaceAllString(data.Group, "_")
+	data.RP = reUnsafeName.ReplaceAllString(data.RP, "_")
+	data.SC = reUnsafeName.ReplaceAllString(data.SC, "_")
+	data.Ticket = reUnsafeName.ReplaceAllString(data.Ticket, "_")
+	data.Profile = reUnsafeName.ReplaceAllString(data.Profile, "_")
+	data.Format = reUnsafeName.ReplaceAllString(data.Format, "_")
+	data.Type = reUnsafeName.ReplaceAllString(data.Type, "_")
+	data.RunID = reUnsafeName.ReplaceAllString(data.RunID, "_")
+	vars := make(map[string]string, len(data.Vars))
+	for name, value := range data.Vars {
+		vars[name] = reUnsafeName.ReplaceAllString(value, "_")
+	}
+	data.Vars = vars
+
+	var builder strings.Builder
+	if err := fileTemplate.Execute(&builder, data); err != nil {
+		return "", err
+	}
+
+	// template may contain dirs('/'), every part of path is sanitized
+	parts := strings.Split(filepath.ToSlash(builder.String()), "/")
+	cleanParts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = sanitizeName(part); part != "" {
+			cleanParts = append(cleanParts, part)
+		}
+	}
+	if len(cleanParts) == 0 {
+		return "", fmt.Errorf("file name is empty")
+	}
+
+	return filepath.Join(cleanParts...), nil
+}
+
+// replace unsafe characters of path part by '_', trim spaces & dots('..' can't be used to leave reports dir)
+func sanitizeName(name string) string {
+	name = reUnsafeName.ReplaceAllString(name, "_")
+	return strings.Trim(name, " .")
+}
+
+// first non-existing path of '<name>.<ext>', '<name>_2.<ext>', '<name>_3.<ext>'...
+func uniquePath(path string) string {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for ind := 2; ; ind++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, ind, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// report file full path of user in format by file template('output' column of users.csv or '-file-template'),
+// existing file is never overwritten
+//
+// with several formats of user '_<type>' is added to name unless template uses Type
+func (a *app) reportFilePath(user User, slice bool, format string) (string, error) {
+	// GETTING DATES FOR REPORT FILE(requester time zone)
+	requesterPeriod := user.Period.In(a.requesterLocation)
+
+	// names are resolved only with '-ldap'
+	displayName := user.DisplayName
+	if displayName == "" {
+		displayName = user.Username
+	}
+	shortName := user.ShortName
+	if shortName == "" {
+		shortName = user.Username
+	}
+
+	fileTemplate := a.fileTemplate
+	if user.FileTemplate != nil {
+		fileTemplate = user.FileTemplate
+	}
+
+	data := fileNameData{
+		User:        user.Username,
+		DisplayName: displayName,
+		ShortName:   shortName,
+		Group:       user.Group,
+		RP:          user.RP,
+		SC:          user.ServiceCall,
+		Ticket:      user.DBId,
+		Start:       requesterPeriod.Start.Format(fileDateLayout),
+		End:         requesterPeriod.End.Format(fileDateLayout),
+		Profile:     a.reportProfile(user),
+		Format:      "zip",
+		Type:        strings.ToLower(format),
+		RunID:       a.runId,
+		Slice:       slice,
+		Vars:        user.Vars,
+	}
+
+	name, err := renderFileName(fileTemplate, data)
+	if err != nil {
+		return "", fmt.Errorf("FAILURE: render report file name of user(%s):\n\t%v", user.Username, err)
+	}
+
+	if len(user.reportFormats()) > 1 {
+		data.Type = ""
+		if untyped, err := renderFileName(fileTemplate, data); err == nil && untyped == name {
+			ext := filepath.Ext(name)
+			name = strings.TrimSuffix(name, ext) + "_" + strings.ToLower(format) + ext
+		}
+	}
+
+	return uniquePath(filepath.Join(a.resultsPath, name)), nil
+}
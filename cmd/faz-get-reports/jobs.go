@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	fazrep "github.com/slayerjk/faz-get-reports/internal/fazrequests"
+	"github.com/slayerjk/faz-get-reports/internal/period"
+)
+
+// columns of users.csv with header(first row starting with 'user'), only user & start are required
+const (
+	columnUser    = "user"
+	columnStart   = "start"
+	columnEnd     = "end"
+	columnProfile = "profile"
+	columnDevice  = "device"
+	columnFormats = "formats"
+	columnOutput  = "output"
+	columnEmail   = "email"
+	// prefix of extra var column, ex.: 'var:ticket'
+	columnVarPrefix = "var:"
+)
+
+// other names of columns
+var columnAliases = map[string]string{
+	"username":    columnUser,
+	"login":       columnUser,
+	"from":        columnStart,
+	"to":          columnEnd,
+	"format":      columnFormats,
+	"output-name": columnOutput,
+	"recipient":   columnEmail,
+	"recipients":  columnEmail,
+}
+
+// name of extra var: letters, digits & '_'
+var reVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// columns of users.csv header: index of column, nil header - legacy positional rows(user, start[, end])
+type usersHeader struct {
+	columns map[string]int
+	// index of extra var column
+	vars map[string]int
+	size int
+}
+
+// parse header row, nil if row isn't header(legacy file without header)
+func parseUsersHeader(row []string) (*usersHeader, error) {
+	if len(row) == 0 || canonicalColumn(row[0]) != columnUser {
+		return nil, nil
+	}
+
+	header := &usersHeader{columns: make(map[string]int), vars: make(map[string]int), size: len(row)}
+
+	for ind, cell := range row {
+		name := canonicalColumn(cell)
+
+		if strings.HasPrefix(name, columnVarPrefix) {
+			varName := strings.TrimSpace(cell)[len(columnVarPrefix):]
+			if err := checkVarName(varName); err != nil {
+				return nil, fmt.Errorf("column '%s': %v", cell, err)
+			}
+			for existing := range header.vars {
+				if strings.EqualFold(existing, varName) {
+					return nil, fmt.Errorf("duplicate column '%s'(var names are case insensitive)", cell)
+				}
+			}
+			header.vars[varName] = ind
+			continue
+		}
+
+		switch name {
+		case columnUser, columnStart, columnEnd, columnProfile, columnDevice, columnFormats, columnOutput, columnEmail:
+		default:
+			return nil, fmt.Errorf("unknown column '%s'", cell)
+		}
+		if _, ok := header.columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column '%s'", cell)
+		}
+		header.columns[name] = ind
+	}
+
+	if _, ok := header.columns[columnStart]; !ok {
+		return nil, fmt.Errorf("column '%s' is required", columnStart)
+	}
+
+	return header, nil
+}
+
+// lower case column name without BOM & spaces, aliases are replaced
+func canonicalColumn(cell string) string {
+	name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff")))
+	if alias, ok := columnAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// trimmed cell of column, empty if there is no such column or row is short
+func (h *usersHeader) cell(row []string, column string) string {
+	ind, ok := h.columns[column]
+	if !ok || ind >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[ind])
+}
+
+// job of csv row by header columns, legacy positional row(user, start[, end]) if header is nil
+func (h *usersHeader) spec(row []string) (jobSpec, error) {
+	if h == nil {
+		if len(row) < 2 {
+			return jobSpec{}, fmt.Errorf("expected user & period, got %v", row)
+		}
+		if len(row) > 3 {
+			return jobSpec{}, fmt.Errorf("expected user, start & end, got %d cells", len(row))
+		}
+		spec := jobSpec{User: row[0], Start: row[1]}
+		if len(row) == 3 {
+			spec.End = row[2]
+		}
+		return spec, nil
+	}
+
+	if len(row) > h.size {
+		return jobSpec{}, fmt.Errorf("%d cells, header has %d columns", len(row), h.size)
+	}
+
+	spec := jobSpec{
+		User:    h.cell(row, columnUser),
+		Start:   h.cell(row, columnStart),
+		End:     h.cell(row, columnEnd),
+		Profile: h.cell(row, columnProfile),
+		Device:  h.cell(row, columnDevice),
+		Formats: strings.FieldsFunc(h.cell(row, columnFormats), isListSeparator),
+		Output:  h.cell(row, columnOutput),
+		Email:   strings.FieldsFunc(h.cell(row, columnEmail), isListSeparator),
+	}
+
+	if len(h.vars) != 0 {
+		spec.Vars = make(map[string]string, len(h.vars))
+		for name, ind := range h.vars {
+			if ind < len(row) {
+				spec.Vars[name] = strings.TrimSpace(row[ind])
+			} else {
+				spec.Vars[name] = ""
+			}
+		}
+	}
+
+	return spec, nil
+}
+
+// extra var must be identifier and must not override built-in dataset var
+func checkVarName(name string) error {
+	if !reVarName.MatchString(name) {
+		return fmt.Errorf("wrong var name '%s': must be letters, digits & '_'", name)
+	}
+	if _, ok := (User{}).datasetVars()[strings.ToUpper(name)]; ok {
+		return fmt.Errorf("var '%s' overrides built-in var", name)
+	}
+	return nil
+}
+
+// get users of job source(mode 'csv': users.csv, jobs file, stdin or command line args)
+//
+// all jobs are checked, errors of all wrong jobs are returned(with their positions)
+func (a *app) localUsers() ([]User, error) {
+	jobs, err := a.jobSource.Jobs()
+	if err != nil {
+		return nil, fmt.Errorf("FAILURE: read jobs of %s:\n\t%v", a.jobSource, err)
+	}
+
+	var (
+		users    []User
+		jobErrs  []string
+		hasEmail bool
+	)
+
+	parser := a.periodParser()
+
+	for _, job := range jobs {
+		err := job.Err
+		if err == nil {
+			var user User
+			user, err = a.jobUser(job.Spec, parser)
+			if err == nil {
+				user.Pos = job.Pos
+				hasEmail = hasEmail || len(user.Email) != 0
+				users = append(users, user)
+				continue
+			}
+		}
+
+		// nested lines of error are indented under position of job
+		jobErrs = append(jobErrs, fmt.Sprintf("%s: %s", job.Pos, strings.ReplaceAll(err.Error(), "\n\t", "\n\t\t")))
+	}
+
+	if len(jobErrs) != 0 {
+		return nil, fmt.Errorf("FAILURE: jobs of %s:\n\t%s", a.jobSource, strings.Join(jobErrs, "\n\t"))
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("FAILURE: no jobs in %s", a.jobSource)
+	}
+
+	// reports are mailed to email of job, mailing settings are checked before FAZ jobs
+	if hasEmail {
+		if _, err := a.config.loadMailing(); err != nil {
+			return nil, fmt.Errorf("FAILURE: jobs of %s have email, mailing settings are needed:\n\t%v", a.jobSource, err)
+		}
+	}
+
+	return users, nil
+}
+
+// check job & make user of it
+func (a *app) jobUser(spec jobSpec, parser period.Parser) (User, error) {
+	var user User
+
+	user.Username = strings.TrimSpace(spec.User)
+	if user.Username == "" {
+		return user, fmt.Errorf("user is empty")
+	}
+
+	dates := []string{spec.Start}
+	if end := strings.TrimSpace(spec.End); end != "" {
+		dates = append(dates, end)
+	}
+	reportPeriod, err := parser.Parse(dates...)
+	if err != nil {
+		return user, fmt.Errorf("parse period of %s:\n\t%v", user.Username, err)
+	}
+	user.Username = strings.ToUpper(user.Username)
+	user.Period = reportPeriod
+	user.StartDate, user.EndDate = reportPeriod.In(a.fazLocation).Faz()
+
+	user.Profile = strings.TrimSpace(spec.Profile)
+	user.Device = strings.TrimSpace(spec.Device)
+
+	for _, format := range spec.Formats {
+		format = strings.ToUpper(strings.TrimSpace(format))
+		if format == "" {
+			continue
+		}
+		if !slices.Contains(fazrep.ReportFormats, format) {
+			return user, fmt.Errorf("unknown format '%s', must be one of %v", format, fazrep.ReportFormats)
+		}
+		if !slices.Contains(user.Formats, format) {
+			user.Formats = append(user.Formats, format)
+		}
+	}
+
+	for _, recipient := range spec.Email {
+		if strings.TrimSpace(recipient) == "" {
+			continue
+		}
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return user, fmt.Errorf("wrong email '%s': %v", recipient, err)
+		}
+		user.Email = append(user.Email, address.Address)
+	}
+
+	for name := range spec.Vars {
+		if err := checkVarName(name); err != nil {
+			return user, err
+		}
+	}
+	user.Vars = spec.Vars
+
+	if output := strings.TrimSpace(spec.Output); output != "" {
+		// template is checked below with data of job
+		user.FileTemplate, err = template.New("file").Option("missingkey=error").Parse(output)
+		if err != nil {
+			return user, fmt.Errorf("failed to parse output template(%s):\n\t%v", output, err)
+		}
+	}
+
+	// file template(also '-file-template') may use vars of job
+	for _, format := range user.reportFormats() {
+		if _, err := a.reportFilePath(user, false, format); err != nil {
+			return user, errors.New(strings.TrimPrefix(err.Error(), "FAILURE: "))
+		}
+	}
+
+	return user, nil
+}
+
+// formats of report files of user, PDF by default
+func (user User) reportFormats() []string {
+	if len(user.Formats) == 0 {
+		return []string{"PDF"}
+	}
+	return user.Formats
+}
+
+// FAZ report(layout) name of user: profile of job or faz-report-name
+func (a *app) reportProfile(user User) string {
+	if user.Profile != "" {
+		return user.Profile
+	}
+	return a.fazModel.FazReportName
+}
+
+// FAZ device of user: device of job or faz-device
+func (a *app) reportDevice(user User) string {
+	if user.Device != "" {
+		return user.Device
+	}
+	return a.fazModel.FazDevice
+}
+
+// '-dry-run': check config & jobs and print parsed jobs, exit code 1 on errors
+func dryRunCsv(a *app, optionsErr error) int {
+	if a.mode != "csv" {
+		fmt.Fprintf(os.Stderr, "'-dry-run' is supported by mode 'csv' only\n")
+		return 1
+	}
+	if optionsErr != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", optionsErr)
+		return 1
+	}
+	if err := a.loadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	users, err := a.localUsers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	if err := a.printUsers(os.Stdout, users); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// print parsed jobs('-dry-run'), nothing is sent to FAZ
+func (a *app) printUsers(output io.Writer, users []User) error {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "POS\tUSER\tSTART\tEND\tPROFILE\tDEVICE\tFORMATS\tEMAIL\tFILES")
+
+	for _, user := range users {
+		requesterPeriod := user.Period.In(a.requesterLocation)
+
+		var files []string
+		for _, format := range user.reportFormats() {
+			path, err := a.reportFilePath(user, false, format)
+			if err != nil {
+				return err
+			}
+			files = append(files, path)
+		}
+
+		email := strings.Join(user.Email, ",")
+		if email == "" {
+			email = "-"
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			user.Pos, user.Username,
+			requesterPeriod.Start.Format("02.01.2006 15:04:05"), requesterPeriod.End.Format("02.01.2006 15:04:05"),
+			a.reportProfile(user), a.reportDevice(user),
+			strings.Join(user.reportFormats(), ","), email, strings.Join(files, ","))
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	fazrep "github.com/slayerjk/faz-get-reports/internal/fazrequests"
+)
+
+func TestParseUsersHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []string
+		// nil - legacy row(not header)
+		columns map[string]int
+		vars    map[string]int
+		err     string
+	}{
+		{
+			name:    "columns",
+			row:     []string{"user", "start", "end", "profile", "device", "formats", "output", "email"},
+			columns: map[string]int{"user": 0, "start": 1, "end": 2, "profile": 3, "device": 4, "formats": 5, "output": 6, "email": 7},
+			vars:    map[string]int{},
+		},
+		{
+			name:    "aliases, case & spaces",
+			row:     []string{"\ufeffLogin", " From", "TO ", "Format", "output-name", "Recipients"},
+			columns: map[string]int{"user": 0, "start": 1, "end": 2, "formats": 3, "output": 4, "email": 5},
+			vars:    map[string]int{},
+		},
+		{
+			name:    "var columns",
+			row:     []string{"username", "start", "var:ticket", "Var:Owner_2"},
+			columns: map[string]int{"user": 0, "start": 1},
+			vars:    map[string]int{"ticket": 2, "Owner_2": 3},
+		},
+		{name: "legacy row", row: []string{"USER1", "01.07.2025", "05.07.2025"}},
+		{name: "empty row", row: []string{}},
+		{name: "unknown column", row: []string{"user", "start", "finish"}, err: "unknown column 'finish'"},
+		{name: "duplicate column", row: []string{"user", "start", "login"}, err: "duplicate column 'login'"},
+		{name: "duplicate alias column", row: []string{"user", "from", "start"}, err: "duplicate column 'start'"},
+		{name: "duplicate var column", row: []string{"user", "start", "var:ticket", "var:TICKET"}, err: "duplicate column 'var:TICKET'"},
+		{name: "wrong var name", row: []string{"user", "start", "var:2nd"}, err: "wrong var name '2nd'"},
+		{name: "var overrides built-in", row: []string{"user", "start", "var:members"}, err: "overrides built-in var"},
+		{name: "no start column", row: []string{"user", "end"}, err: "column 'start' is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := parseUsersHeader(tt.row)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("parseUsersHeader() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUsersHeader() error: %v", err)
+			}
+
+			if tt.columns == nil {
+				if header != nil {
+					t.Errorf("parseUsersHeader() = %+v, want nil(legacy row)", header)
+				}
+				return
+			}
+			if header == nil {
+				t.Fatalf("parseUsersHeader() = nil, want header")
+			}
+			if !reflect.DeepEqual(header.columns, tt.columns) {
+				t.Errorf("columns = %v, want %v", header.columns, tt.columns)
+			}
+			if !reflect.DeepEqual(header.vars, tt.vars) {
+				t.Errorf("vars = %v, want %v", header.vars, tt.vars)
+			}
+			if header.size != len(tt.row) {
+				t.Errorf("size = %d, want %d", header.size, len(tt.row))
+			}
+		})
+	}
+}
+
+func TestReadCsvJobs(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		withHeader bool
+		want       []sourceJob
+		// errors of jobs by index
+		jobErrs map[int]string
+		err     string
+	}{
+		{
+			name:       "legacy rows",
+			content:    "# user, start, end\nUSER1,01.07.2025,05.07.2025\n\n,,\nUSER2, 2025-07\n",
+			withHeader: true,
+			want: []sourceJob{
+				{Pos: "line 2", Spec: jobSpec{User: "USER1", Start: "01.07.2025", End: "05.07.2025"}},
+				{Pos: "line 5", Spec: jobSpec{User: "USER2", Start: "2025-07"}},
+			},
+		},
+		{
+			name:       "legacy rows of stdin",
+			content:    "user,start\n",
+			withHeader: false,
+			want:       []sourceJob{{Pos: "line 1", Spec: jobSpec{User: "user", Start: "start"}}},
+		},
+		{
+			name: "per-row options",
+			content: "user,from,end,profile,device,formats,output,email,var:ticket\n" +
+				"USER1,01.07.2025,,Report VPN,FGT1,pdf|csv,{{.User}}.{{.Format}},\"a@corp.local,b@corp.local\",42\n" +
+				"USER2,2025-07\n",
+			withHeader: true,
+			want: []sourceJob{
+				{Pos: "line 2", Spec: jobSpec{
+					User: "USER1", Start: "01.07.2025", Profile: "Report VPN", Device: "FGT1",
+					Formats: listValue{"pdf", "csv"}, Output: "{{.User}}.{{.Format}}", Email: listValue{"a@corp.local", "b@corp.local"},
+					Vars: map[string]string{"ticket": "42"},
+				}},
+				// omitted cells are empty
+				{Pos: "line 3", Spec: jobSpec{User: "USER2", Start: "2025-07", Formats: listValue{}, Email: listValue{}, Vars: map[string]string{"ticket": ""}}},
+			},
+		},
+		{
+			name:       "wrong rows",
+			content:    "USER1\nUSER2,01.07.2025,02.07.2025,03.07.2025\nUSER3,01.07.2025\n",
+			withHeader: true,
+			jobErrs:    map[int]string{0: "expected user & period", 1: "expected user, start & end"},
+		},
+		{
+			name:       "row longer than header",
+			content:    "user,start\nUSER1,01.07.2025,02.07.2025\n",
+			withHeader: true,
+			jobErrs:    map[int]string{0: "3 cells, header has 2 columns"},
+		},
+		{
+			name:       "wrong header",
+			content:    "\n# comment\nuser,start,finish\nUSER1,01.07.2025\n",
+			withHeader: true,
+			err:        "line 3: header: unknown column 'finish'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := readCsvJobs(strings.NewReader(tt.content), tt.withHeader)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("readCsvJobs() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readCsvJobs() error: %v", err)
+			}
+
+			if tt.jobErrs != nil {
+				for ind, want := range tt.jobErrs {
+					if ind >= len(jobs) || jobs[ind].Err == nil || !strings.Contains(jobs[ind].Err.Error(), want) {
+						t.Errorf("readCsvJobs() = %+v, want error %q of job %d", jobs, want, ind+1)
+					}
+				}
+				return
+			}
+			if !reflect.DeepEqual(jobs, tt.want) {
+				t.Errorf("readCsvJobs() = %+v, want %+v", jobs, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintUsers(t *testing.T) {
+	fileTemplate, err := parseFileTemplate("", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &app{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mode:   "csv",
+		jobSource: csvSource{Path: writeJobsFile(t, "users.csv",
+			"user,start,end,profile,formats,var:ticket\n"+
+				"user1,01.07.2025,02.07.2025,,,\n"+
+				"user2,01.07.2025,01.07.2025,Report VPN,pdf|csv,42\n")},
+		resultsPath:       "Reports",
+		requesterLocation: time.UTC,
+		fazLocation:       time.UTC,
+		fazModel:          &fazrep.FazModelJson{FazReportName: "Report", FazDevice: "All_FortiGate"},
+		fileTemplate:      fileTemplate,
+	}
+
+	users, err := a.localUsers()
+	if err != nil {
+		t.Fatalf("localUsers() error: %v", err)
+	}
+
+	var output bytes.Buffer
+	if err := a.printUsers(&output, users); err != nil {
+		t.Fatalf("printUsers() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("printUsers() = %q, want header & 2 jobs", output.String())
+	}
+	want := [][]string{
+		{"POS", "USER", "START", "END", "PROFILE", "DEVICE", "FORMATS", "EMAIL", "FILES"},
+		{"line", "2", "USER1", "01.07.2025", "00:00:00", "02.07.2025", "23:59:59", "Report", "All_FortiGate", "PDF", "-",
+			filepath.Join("Reports", "USER1_01-07-2025-T-00-00-00_02-07-2025-T-23-59-59.zip")},
+		{"line", "3", "USER2", "01.07.2025", "00:00:00", "01.07.2025", "23:59:59", "Report", "VPN", "All_FortiGate", "PDF,CSV", "-",
+			filepath.Join("Reports", "USER2_01-07-2025-T-00-00-00_01-07-2025-T-23-59-59_pdf.zip") + "," + filepath.Join("Reports", "USER2_01-07-2025-T-00-00-00_01-07-2025-T-23-59-59_csv.zip")},
+	}
+	for ind, line := range lines {
+		if got := strings.Fields(line); !reflect.DeepEqual(got, want[ind]) {
+			t.Errorf("line %d of printUsers() = %q, want %q", ind+1, got, want[ind])
+		}
+	}
+}
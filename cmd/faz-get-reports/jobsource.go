@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// source of report jobs of mode 'csv': users.csv, jobs file(JSON/YAML), stdin or command line args
+type jobSource interface {
+	// name of source for logs & errors, ex.: file path
+	String() string
+	// jobs in order of source, wrong jobs have Err(all jobs are read to report all errors at once);
+	// error is returned if source can't be read at all
+	Jobs() ([]sourceJob, error)
+}
+
+// job of source
+type sourceJob struct {
+	// position of job in source, ex.: 'line 3', 'job 2'
+	Pos  string
+	Spec jobSpec
+	// job can't be decoded
+	Err error
+}
+
+// report job as given by source, it's checked & converted to User by jobUser
+//
+// keys are the same as users.csv header columns
+//
+// YAML scalars are decoded as written(ex.: unquoted date '2025-07-01' or numeric user '1234' are strings)
+type jobSpec struct {
+	User    string    `json:"user" yaml:"user"`
+	Start   string    `json:"start" yaml:"start"`
+	End     string    `json:"end" yaml:"end"`
+	Profile string    `json:"profile" yaml:"profile"`
+	Device  string    `json:"device" yaml:"device"`
+	Formats listValue `json:"formats" yaml:"formats"`
+	Output  string    `json:"output" yaml:"output"`
+	Email   listValue `json:"email" yaml:"email"`
+	// extra vars: '%NAME%' in dataset queries, '{{.Vars.NAME}}' in file templates
+	Vars map[string]string `json:"vars" yaml:"vars"`
+}
+
+// list of strings: JSON/YAML list or string separated by '|', ';', ' ' or ','
+type listValue []string
+
+func (l *listValue) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*l = strings.FieldsFunc(text, isListSeparator)
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("must be string or list of strings: %s", data)
+	}
+	*l = list
+
+	return nil
+}
+
+func (l *listValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = strings.FieldsFunc(node.Value, isListSeparator)
+		return nil
+	}
+
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return fmt.Errorf("line %d: must be string or list of strings", node.Line)
+	}
+	*l = list
+
+	return nil
+}
+
+// separators of list values(formats, email): ',' needs quoting in csv cell
+func isListSeparator(r rune) bool {
+	return r == ',' || r == ';' || r == '|' || r == ' '
+}
+
+// source of jobs by flags: command line args('-user'), jobs file or stdin('-jobs'), users.csv otherwise
+func newJobSource(user, from, to, jobsFile, usersFile string) jobSource {
+	switch {
+	case user != "":
+		return argsSource{Spec: jobSpec{User: user, Start: from, End: to}}
+	case jobsFile == "-":
+		return stdinSource{Reader: os.Stdin}
+	case jobsFile != "" && strings.EqualFold(filepath.Ext(jobsFile), ".csv"):
+		return csvSource{Path: jobsFile}
+	case jobsFile != "":
+		return fileSource{Path: jobsFile}
+	default:
+		return csvSource{Path: usersFile}
+	}
+}
+
+// one job of command line args: '-user X -from ... -to ...'
+type argsSource struct {
+	Spec jobSpec
+}
+
+func (s argsSource) String() string {
+	return "command line args"
+}
+
+func (s argsSource) Jobs() ([]sourceJob, error) {
+	return []sourceJob{{Pos: "args", Spec: s.Spec}}, nil
+}
+
+// users.csv: header columns(first row starting with 'user') or legacy positional rows(user, start[, end])
+type csvSource struct {
+	Path string
+}
+
+func (s csvSource) String() string {
+	return s.Path
+}
+
+func (s csvSource) Jobs() ([]sourceJob, error) {
+	usersFile, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open users file:\n\t%v", err)
+	}
+	defer usersFile.Close()
+
+	return readCsvJobs(usersFile, true)
+}
+
+// jobs of csv rows, first row may be header(withHeader), '#' comments & blank rows are skipped
+func readCsvJobs(reader io.Reader, withHeader bool) ([]sourceJob, error) {
+	csvreader := csv.NewReader(reader)
+	// end date may be omitted(single day, month or relative period), optional columns may be omitted
+	csvreader.FieldsPerRecord = -1
+	csvreader.Comment = '#'
+	csvreader.TrimLeadingSpace = true
+
+	var (
+		header *usersHeader
+		jobs   []sourceJob
+		first  = withHeader
+	)
+
+	for {
+		row, err := csvreader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// reading can't go on after parse error
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				jobs = append(jobs, sourceJob{Pos: fmt.Sprintf("line %d", parseErr.Line), Err: parseErr.Err})
+				break
+			}
+			return nil, fmt.Errorf("failed to read csv:\n\t%v", err)
+		}
+		line, _ := csvreader.FieldPos(0)
+
+		// blank rows(ex.: ',,')
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		if first {
+			first = false
+			header, err = parseUsersHeader(row)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: header: %v", line, err)
+			}
+			if header != nil {
+				continue
+			}
+		}
+
+		spec, err := header.spec(row)
+		jobs = append(jobs, sourceJob{Pos: fmt.Sprintf("line %d", line), Spec: spec, Err: err})
+	}
+
+	return jobs, nil
+}
+
+// jobs file: JSON('.json') or YAML, list of jobs or map with 'jobs' list
+type fileSource struct {
+	Path string
+}
+
+func (s fileSource) String() string {
+	return s.Path
+}
+
+func (s fileSource) Jobs() ([]sourceJob, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs file:\n\t%v", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(s.Path), ".json") {
+		return readJsonJobs(data)
+	}
+	return readYamlJobs(data)
+}
+
+// jobs of JSON file: list of jobs or object with 'jobs' list
+func readJsonJobs(data []byte) ([]sourceJob, error) {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs file:\n\t%v", err)
+	}
+
+	if jobsMap, ok := raw.(map[string]any); ok {
+		for key := range jobsMap {
+			if key != "jobs" {
+				return nil, fmt.Errorf("unknown key '%s' of jobs file(only 'jobs' list is expected)", key)
+			}
+		}
+		raw = jobsMap["jobs"]
+	}
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("jobs file must be list of jobs or map with 'jobs' list")
+	}
+
+	jobs := make([]sourceJob, 0, len(items))
+	for ind, item := range items {
+		job := sourceJob{Pos: fmt.Sprintf("job %d", ind+1)}
+
+		itemData, err := json.Marshal(item)
+		if err != nil {
+			job.Err = err
+		} else {
+			job.Spec, job.Err = decodeJobSpec(itemData)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// jobs of YAML file: list of jobs or map with 'jobs' list
+//
+// jobs are decoded from nodes, so scalars keep their text(no dates, numbers & bools)
+func readYamlJobs(data []byte) ([]sourceJob, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs file:\n\t%v", err)
+	}
+
+	var list *yaml.Node
+	if len(document.Content) == 1 {
+		list = document.Content[0]
+	}
+
+	if list != nil && list.Kind == yaml.MappingNode {
+		jobsMap := list
+		list = nil
+		for ind := 0; ind+1 < len(jobsMap.Content); ind += 2 {
+			if key := jobsMap.Content[ind].Value; key != "jobs" {
+				return nil, fmt.Errorf("unknown key '%s' of jobs file(only 'jobs' list is expected)", key)
+			}
+			list = jobsMap.Content[ind+1]
+		}
+	}
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("jobs file must be list of jobs or map with 'jobs' list")
+	}
+
+	jobs := make([]sourceJob, 0, len(list.Content))
+	for ind, item := range list.Content {
+		job := sourceJob{Pos: fmt.Sprintf("job %d", ind+1)}
+		job.Spec, job.Err = decodeYamlJobSpec(item)
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// job of YAML mapping node, unknown keys are errors
+func decodeYamlJobSpec(node *yaml.Node) (jobSpec, error) {
+	var spec jobSpec
+
+	if node.Kind != yaml.MappingNode {
+		return spec, fmt.Errorf("wrong job: line %d: job must be map", node.Line)
+	}
+
+	known := make(map[string]bool)
+	specType := reflect.TypeOf(spec)
+	for ind := 0; ind < specType.NumField(); ind++ {
+		known[specType.Field(ind).Tag.Get("yaml")] = true
+	}
+	for ind := 0; ind < len(node.Content); ind += 2 {
+		if key := node.Content[ind].Value; !known[key] {
+			return spec, fmt.Errorf("wrong job: line %d: unknown key '%s'", node.Content[ind].Line, key)
+		}
+	}
+
+	if err := node.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("wrong job: %v", err)
+	}
+
+	return spec, nil
+}
+
+// stdin: one job per line, JSON object('{"user": ...}') or csv row without header(user, start[, end]);
+// blank lines & '#' comments are skipped
+type stdinSource struct {
+	Reader io.Reader
+}
+
+func (s stdinSource) String() string {
+	return "stdin"
+}
+
+func (s stdinSource) Jobs() ([]sourceJob, error) {
+	var jobs []sourceJob
+
+	scanner := bufio.NewScanner(s.Reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		job := sourceJob{Pos: fmt.Sprintf("line %d", line)}
+		if strings.HasPrefix(text, "{") {
+			job.Spec, job.Err = decodeJobSpec([]byte(text))
+		} else {
+			rowJobs, err := readCsvJobs(strings.NewReader(text), false)
+			switch {
+			case err != nil:
+				job.Err = err
+			case len(rowJobs) == 1:
+				job.Spec, job.Err = rowJobs[0].Spec, rowJobs[0].Err
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stdin:\n\t%v", err)
+	}
+
+	return jobs, nil
+}
+
+// job of JSON object, unknown keys are errors
+func decodeJobSpec(data []byte) (jobSpec, error) {
+	var spec jobSpec
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("wrong job: %v", err)
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeJobsFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileSourceYaml(t *testing.T) {
+	path := writeJobsFile(t, "jobs.yaml", `
+jobs:
+  # unquoted dates & numbers are kept as written
+  - user: 1234
+    start: 2025-07-01
+    end: 2025-07-05 18:00
+    vars: {ticket: 42, urgent: yes}
+  - user: USER2
+    start: 01.07.2025
+    formats: PDF|CSV
+    email: [a@corp.local, b@corp.local]
+  - user: USER3
+    start: 2025-07
+    formats: [html]
+`)
+
+	jobs, err := fileSource{Path: path}.Jobs()
+	if err != nil {
+		t.Fatalf("Jobs() error: %v", err)
+	}
+
+	want := []sourceJob{
+		{Pos: "job 1", Spec: jobSpec{User: "1234", Start: "2025-07-01", End: "2025-07-05 18:00", Vars: map[string]string{"ticket": "42", "urgent": "yes"}}},
+		{Pos: "job 2", Spec: jobSpec{User: "USER2", Start: "01.07.2025", Formats: listValue{"PDF", "CSV"}, Email: listValue{"a@corp.local", "b@corp.local"}}},
+		{Pos: "job 3", Spec: jobSpec{User: "USER3", Start: "2025-07", Formats: listValue{"html"}}},
+	}
+	if !reflect.DeepEqual(jobs, want) {
+		t.Errorf("Jobs() = %+v, want %+v", jobs, want)
+	}
+}
+
+func TestFileSourceJson(t *testing.T) {
+	path := writeJobsFile(t, "jobs.json", `[
+		{"user": "USER1", "start": "2025-07-01", "formats": "pdf,csv"},
+		{"user": "USER2", "start": "2025-07-01", "unknown": 1}
+	]`)
+
+	jobs, err := fileSource{Path: path}.Jobs()
+	if err != nil {
+		t.Fatalf("Jobs() error: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("Jobs() = %+v, want 2 jobs", jobs)
+	}
+	if want := (jobSpec{User: "USER1", Start: "2025-07-01", Formats: listValue{"pdf", "csv"}}); jobs[0].Err != nil || !reflect.DeepEqual(jobs[0].Spec, want) {
+		t.Errorf("job 1 = %+v, want %+v", jobs[0], want)
+	}
+	if jobs[1].Err == nil {
+		t.Errorf("job 2 with unknown key has no error")
+	}
+}
+
+func TestFileSourceYamlErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// error of file(empty - error of first job)
+		fileErr string
+		jobErr  string
+	}{
+		{name: "unknown top key", content: "tasks:\n  - user: USER1\n", fileErr: "unknown key 'tasks'"},
+		{name: "not a list", content: "jobs: USER1\n", fileErr: "must be list of jobs"},
+		{name: "empty file", content: "", fileErr: "must be list of jobs"},
+		{name: "unknown job key", content: "- user: USER1\n  start: 2025-07-01\n  finish: 2025-07-02\n", jobErr: "unknown key 'finish'"},
+		{name: "job is not map", content: "- USER1\n", jobErr: "job must be map"},
+		{name: "wrong formats", content: "- user: USER1\n  formats: {pdf: 1}\n", jobErr: "must be string or list of strings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := fileSource{Path: writeJobsFile(t, "jobs.yml", tt.content)}.Jobs()
+			if tt.fileErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.fileErr) {
+					t.Fatalf("Jobs() error = %v, want %q", err, tt.fileErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Jobs() error: %v", err)
+			}
+			if len(jobs) != 1 || jobs[0].Err == nil || !strings.Contains(jobs[0].Err.Error(), tt.jobErr) {
+				t.Errorf("Jobs() = %+v, want job error %q", jobs, tt.jobErr)
+			}
+		})
+	}
+}
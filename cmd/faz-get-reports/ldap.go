@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/slayerjk/faz-get-reports/internal/ldapresolver"
+)
+
+// prefix of AD group in users list(ticket & users.csv), ex.: 'group:VPN-Contractors'
+const groupPrefix = "GROUP:"
+
+// report modes of AD group('-group-report')
+const (
+	// one report job per member
+	groupReportMembers = "members"
+	// one report of all members(dataset queries must use '%MEMBERS%')
+	groupReportCombined = "combined"
+)
+
+// name of AD group if username is a group entry('group:<NAME>')
+func groupName(username string) (string, bool) {
+	if len(username) < len(groupPrefix) || !strings.EqualFold(username[:len(groupPrefix)], groupPrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(username[len(groupPrefix):]), true
+}
+
+// placeholders of FAZ dataset queries(ex.: '%USERNAME%'), resolved names are empty without '-ldap'
+//
+// values are escaped for SQL string literal(every single quote is doubled, ex.: O'Brien);
+// MEMBERS is quoted list of accounts for SQL 'IN'(ex.: 'USER1','USER2'), the user itself if it's not a combined group report
+func (user User) datasetVars() map[string]string {
+	members := user.Members
+	if len(members) == 0 {
+		members = []string{user.Username}
+	}
+	quoted := make([]string, 0, len(members))
+	for _, member := range members {
+		quoted = append(quoted, "'"+sqlEscape(member)+"'")
+	}
+
+	vars := map[string]string{
+		"USERNAME":    sqlEscape(user.Username),
+		"DISPLAYNAME": sqlEscape(user.DisplayName),
+		"SHORTNAME":   sqlEscape(user.ShortName),
+		"UPN":         sqlEscape(user.UPN),
+		"GROUP":       sqlEscape(user.Group),
+		"MEMBERS":     strings.Join(quoted, ","),
+	}
+	// extra vars of users.csv row can't override built-in ones(checked by header)
+	for name, value := range user.Vars {
+		vars[strings.ToUpper(name)] = sqlEscape(value)
+	}
+
+	return vars
+}
+
+// escape value for SQL string literal
+func sqlEscape(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+// users with failures: ticket of failed user is marked as failed or skipped till next run(mode 'naumen'), failed users of users.csv are skipped
+type userFailures struct {
+	app     *app
+	tickets *naumenTickets
+	// failed tickets(mode 'naumen')
+	failedTickets map[string]bool
+}
+
+func (a *app) newUserFailures(tickets *naumenTickets) *userFailures {
+	return &userFailures{app: a, tickets: tickets, failedTickets: make(map[string]bool)}
+}
+
+// ticket of user is failed already(mode 'naumen')
+func (f *userFailures) failed(user User) bool {
+	return f.failedTickets[user.DBId]
+}
+
+// fail ticket of user(mode 'naumen') or report user to be skipped, action is what failed(ex.: 'resolve user X in AD')
+func (f *userFailures) fail(user User, action string, err error) {
+	if f.app.naumenMode {
+		f.failedTickets[user.DBId] = true
+		f.tickets.remove(user.DBId)
+		f.app.failDbValue(user.DBId, user.ServiceCall, fmt.Sprintf("FAILURE: %s of %s:\n\t%v", action, user.DBId, err))
+		return
+	}
+	f.app.reportError(fmt.Sprintf("FAILURE: %s, skipping:\n\t%v", action, err))
+}
+
+// skip ticket of user till next run(mode 'naumen'): error may not repeat(ex.: FAZ outage), so db value stays unprocessed
+func (f *userFailures) retry(user User, action string, err error) {
+	f.failedTickets[user.DBId] = true
+	f.tickets.remove(user.DBId)
+	f.app.reportError(fmt.Sprintf("FAILURE: %s of %s, it's retried on next run:\n\t%v", action, user.DBId, err))
+}
+
+// drop users of failed tickets(added before failure), errNoValues if no users remain
+func (f *userFailures) keep(users []User) ([]User, error) {
+	result := make([]User, 0, len(users))
+	for _, user := range users {
+		if !f.failedTickets[user.DBId] {
+			result = append(result, user)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, errNoValues
+	}
+
+	return result, nil
+}
+
+// check users exist & are enabled in AD and resolve their names, expand AD groups('group:<NAME>')
+//
+// ticket with unknown/disabled user or unknown/empty group is marked as failed(mode 'naumen'), such users of users.csv are skipped
+func (a *app) resolveUsers(users []User, tickets *naumenTickets) ([]User, error) {
+	resolver, err := ldapresolver.Dial(a.ldapConfig)
+	if err != nil {
+		return nil, fmt.Errorf("FAILURE: connect to LDAP:\n\t%v", err)
+	}
+	defer resolver.Close()
+
+	failures := a.newUserFailures(tickets)
+	resolved := make([]User, 0, len(users))
+
+	for _, user := range users {
+		if failures.failed(user) {
+			continue
+		}
+
+		if group, ok := groupName(user.Username); ok {
+			groupUsers, err := a.expandGroup(resolver, user, group)
+			if err != nil {
+				failures.fail(user, fmt.Sprintf("expand AD group %s", group), err)
+				continue
+			}
+			resolved = append(resolved, groupUsers...)
+			continue
+		}
+
+		identity, err := resolver.Resolve(user.Username)
+		if err != nil {
+			failures.fail(user, fmt.Sprintf("resolve user %s in AD", user.Username), err)
+			continue
+		}
+
+		a.logger.Info("resolved user in AD", "USR", user.Username, "SAM", identity.SAMAccountName, "NAME", identity.DisplayName, "UPN", identity.UPN)
+
+		resolved = append(resolved, user.withIdentity(*identity))
+	}
+
+	return failures.keep(resolved)
+}
+
+// users of AD group entry: one per enabled member or one combined user of group('-group-report')
+func (a *app) expandGroup(resolver *ldapresolver.Resolver, user User, group string) ([]User, error) {
+	members, disabled, err := resolver.GroupMembers(group)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("group %s has no enabled members", group)
+	}
+
+	accounts := make([]string, 0, len(members))
+	for _, member := range members {
+		accounts = append(accounts, strings.ToUpper(member.SAMAccountName))
+	}
+
+	// expansion is kept in run log
+	a.logger.Info("expanded AD group", "GROUP", group, "VAL", user.DBId, "MODE", a.groupReport, slog.Any("MEMBERS", accounts), slog.Any("DISABLED", disabled))
+
+	if a.groupReport == groupReportCombined {
+		// without %MEMBERS% report would be of group name(any other placeholder is replaced by username)
+		if err := a.checkMembersPlaceholder(); err != nil {
+			return nil, err
+		}
+
+		user.Username = strings.ToUpper(group)
+		user.DisplayName = group
+		user.ShortName = group
+		user.Group = group
+		user.Members = accounts
+		return []User{user}, nil
+	}
+
+	result := make([]User, 0, len(members))
+	for _, member := range members {
+		memberUser := user.withIdentity(member)
+		memberUser.Group = group
+		result = append(result, memberUser)
+	}
+
+	return result, nil
+}
+
+// every FAZ dataset query must use %MEMBERS% in combined group report
+func (a *app) checkMembersPlaceholder() error {
+	for _, dataset := range a.fazModel.FazDatasets {
+		if !strings.Contains(strings.ToUpper(dataset["dataset-query"]), "%MEMBERS%") {
+			return fmt.Errorf("query of FAZ dataset %s has no %%MEMBERS%% placeholder(required by '-group-report %s')", dataset["dataset"], groupReportCombined)
+		}
+	}
+	return nil
+}
+
+// user with AD account & names of identity
+func (user User) withIdentity(identity ldapresolver.Identity) User {
+	user.Username = strings.ToUpper(identity.SAMAccountName)
+	user.DisplayName = identity.DisplayName
+	user.ShortName = identity.ShortName
+	user.UPN = identity.UPN
+	return user
+}
+
+// AD groups can't be expanded without '-ldap': their tickets are marked as failed(mode 'naumen'), rows of users.csv are skipped
+func (a *app) rejectGroups(users []User, tickets *naumenTickets) ([]User, error) {
+	failures := a.newUserFailures(tickets)
+	result := make([]User, 0, len(users))
+
+	for _, user := range users {
+		if failures.failed(user) {
+			continue
+		}
+		if group, ok := groupName(user.Username); ok {
+			failures.fail(user, fmt.Sprintf("expand AD group %s", group), fmt.Errorf("AD groups require '-ldap'"))
+			continue
+		}
+		result = append(result, user)
+	}
+
+	return failures.keep(result)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	fazrep "github.com/slayerjk/faz-get-reports/internal/fazrequests"
+)
+
+func TestDatasetVars(t *testing.T) {
+	tests := []struct {
+		name string
+		user User
+		want map[string]string
+	}{
+		{
+			name: "regular user",
+			user: User{Username: "OBRIEN", DisplayName: "O'Brien Pat", ShortName: "O'Brien P.", UPN: "o'brien@corp.local"},
+			want: map[string]string{
+				"USERNAME":    "OBRIEN",
+				"DISPLAYNAME": "O''Brien Pat",
+				"SHORTNAME":   "O''Brien P.",
+				"UPN":         "o''brien@corp.local",
+				"GROUP":       "",
+				"MEMBERS":     "'OBRIEN'",
+			},
+		},
+		{
+			name: "combined group report",
+			user: User{Username: "group:Sales'", Group: "Sales'", Members: []string{"USER1", "O'BRIEN"}, Vars: map[string]string{"dept": "R'n'D"}},
+			want: map[string]string{
+				"USERNAME":    "group:Sales''",
+				"DISPLAYNAME": "",
+				"SHORTNAME":   "",
+				"UPN":         "",
+				"GROUP":       "Sales''",
+				"MEMBERS":     "'USER1','O''BRIEN'",
+				"DEPT":        "R''n''D",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.datasetVars(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("datasetVars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMembersPlaceholder(t *testing.T) {
+	tests := []struct {
+		name     string
+		datasets []map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "all queries use members",
+			datasets: []map[string]string{
+				{"dataset": "all", "dataset-query": "select * from $log where `user` in (%MEMBERS%)"},
+				{"dataset": "total", "dataset-query": "select * from $log where `user` in (%members%)"},
+			},
+		},
+		{
+			name: "query with username only",
+			datasets: []map[string]string{
+				{"dataset": "all", "dataset-query": "select * from $log where `user` in (%MEMBERS%)"},
+				{"dataset": "total", "dataset-query": "select * from $log where `user` = '%USERNAME%'"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &app{fazModel: &fazrep.FazModelJson{FazDatasets: tt.datasets}}
+			if err := a.checkMembersPlaceholder(); (err != nil) != tt.wantErr {
+				t.Errorf("checkMembersPlaceholder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
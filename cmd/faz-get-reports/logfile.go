@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	vafswork "github.com/slayerjk/go-vafswork"
+)
+
+// log file writer: '<logsDir>/<appName>_<DD.MM.YYYY>.log', opened in append mode
+//
+// switches to new file(and rotates old ones) when date changes, so long-running 'serve' keeps daily logs
+type dailyLogFile struct {
+	mu         sync.Mutex
+	logsDir    string
+	logsToKeep int
+	date       string
+	file       *os.File
+}
+
+// open log file of current date
+func newDailyLogFile(logsDir string, logsToKeep int) (*dailyLogFile, error) {
+	logFile := &dailyLogFile{logsDir: logsDir, logsToKeep: logsToKeep}
+
+	if err := logFile.open(time.Now().Format("02.01.2006")); err != nil {
+		return nil, err
+	}
+
+	return logFile, nil
+}
+
+// open log file of date and close previous one
+func (l *dailyLogFile) open(date string) error {
+	logFilePath := fmt.Sprintf("%s/%s_%s.log", l.logsDir, appName, date)
+
+	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s:\n\t%v", logFilePath, err)
+	}
+
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = file
+	l.date = date
+
+	return nil
+}
+
+func (l *dailyLogFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if date := time.Now().Format("02.01.2006"); date != l.date && l.file != nil {
+		// keep writing to old file if new one can't be opened
+		if err := l.open(date); err == nil {
+			vafswork.RotateFilesByMtime(l.logsDir, l.logsToKeep)
+		}
+	}
+
+	if l.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	return l.file.Write(p)
+}
+
+// close current log file
+func (l *dailyLogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
@@ -1,92 +1,271 @@
 package main
 
 import (
-	"encoding/base64"
-	"encoding/csv"
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"text/template"
 	"time"
 
-	fazrep "github.com/slayerjk/faz-get-reports/internal/fazrequests"
 	"github.com/slayerjk/faz-get-reports/internal/helpers"
+	"github.com/slayerjk/faz-get-reports/internal/httpclient"
 	models "github.com/slayerjk/faz-get-reports/internal/models"
-	naumen "github.com/slayerjk/go-hd-naumen-api"
-	mailing "github.com/slayerjk/go-mailing"
+	naumenreq "github.com/slayerjk/faz-get-reports/internal/naumenrequests"
+	"github.com/slayerjk/faz-get-reports/internal/netconf"
+	"github.com/slayerjk/faz-get-reports/internal/period"
+	"github.com/slayerjk/faz-get-reports/internal/secrets"
+	"github.com/slayerjk/faz-get-reports/internal/sumparser"
+	"github.com/slayerjk/faz-get-reports/internal/ticketing"
 	vafswork "github.com/slayerjk/go-vafswork"
-	vawebwork "github.com/slayerjk/go-vawebwork"
 )
 
 const (
 	appName               = "faz-get-reports"
 	dbTable               = "Data"
 	dbValueColumn         = "Value"
+	dbPostedDateColumn    = "Posted_Date"
 	dbProcessedColumn     = "Processed"
 	dbProcessedDateColumn = "Processed_Date"
+	dbServiceCallColumn   = "Service_Call"
 )
 
+// Naumen data table of db
+var dbDataTable = models.DataTable{
+	Table:               dbTable,
+	ValueColumn:         dbValueColumn,
+	PostedDateColumn:    dbPostedDateColumn,
+	ProcessedColumn:     dbProcessedColumn,
+	ProcessedDateColumn: dbProcessedDateColumn,
+	ServiceCallColumn:   dbServiceCallColumn,
+}
+
 type naumenData struct {
 	NaumenBaseUrl   string `json:"naumen-base-url"`
 	NaumenAccessKey string `json:"naumen-access-key"`
+	// filter of service calls to find in mode 'naumen-discovery'
+	NaumenDiscovery naumenreq.DiscoveryFilter `json:"naumen-discovery"`
+	// templates of request form to parse sumDescription(built-in default if empty)
+	NaumenTemplates []sumparser.Template `json:"naumen-templates"`
+	// TLS settings of Naumen connection(system roots by default)
+	NaumenTLS httpclient.TLS `json:"naumen-tls"`
+	// proxy, timeouts & retry policy of Naumen connection(only GET requests are retried, attaching files is POST)
+	NaumenNetwork netconf.Settings `json:"naumen-network"`
+	// helpdesk API(Naumen by default), base URL, access key, TLS & network settings above are used by any API
+	Ticketing ticketingData `json:"ticketing"`
+}
+
+// helpdesk API of modes 'naumen' & 'naumen-discovery'
+type ticketingData struct {
+	// 'naumen'(default) or 'rest' - generic REST/webhook API by URL templates of 'rest'
+	Type string `json:"type"`
+	// action on ticket failed by wrong form or users: ''(default) - only mark db value as failed,
+	// 'comment' - also comment ticket with error, 'reject' - also reject ticket with error
+	OnFailure string               `json:"on-failure"`
+	Rest      ticketing.RestConfig `json:"rest"`
 }
 
 type User struct {
-	Username  string
+	Username string
+	// resolved in AD(see '-ldap'), empty if unknown
+	DisplayName string
+	// 'Surname N.P.'
+	ShortName string
+	UPN       string
+	// AD group of user(expanded 'group:<NAME>' entry, see '-group-report')
+	Group string
+	// accounts of combined group report('-group-report combined')
+	Members []string
+	// report period in requester time zone
+	Period period.Period
+	// report period in FAZ format & FAZ time zone
 	StartDate string
 	EndDate   string
+	// id of job in db 'Jobs' table
+	JobId int64
 	// Fields below is only for mode 'naumen'
 	DBId        string
 	ServiceCall string
 	RP          string
+	// Fields below is only for mode 'csv'(optional fields of jobs, ex.: users.csv header columns)
+	// position of job in its source, ex.: 'line 3'
+	Pos string
+	// FAZ report(layout) name, faz-report-name by default
+	Profile string
+	// FAZ device, faz-device by default
+	Device string
+	// formats of report files(fazrep.ReportFormats), PDF by default
+	Formats []string
+	// extra vars of dataset queries('%NAME%') & file template('{{.Vars.NAME}}')
+	Vars map[string]string
+	// report file template of row('output' column), '-file-template' by default
+	FileTemplate *template.Template
+	// recipients of report files
+	Email []string
 }
 
-// struct of Naumen RP summary
-type NaumenRPSummary map[string]map[string][]string
-
 func main() {
 	var (
-		logsPath           = vafswork.GetExePath() + "/logs" + "_" + appName
-		fazModelFilePath   = vafswork.GetExePath() + "/data/faz-data.json"
-		naumenDataFilePath = vafswork.GetExePath() + "/data/naumen-data.json"
-		usersFilePath      = vafswork.GetExePath() + "/data/users.csv"
-		resultsPath        = vafswork.GetExePath() + "/Reports"
-		dbFile             = vafswork.GetExePath() + "/data/data.db"
-		mailingFileDefault = vafswork.GetExePath() + "/data/mailing.json"
-		mailErr            error
-		naumenData         naumenData
-		user               User
-		users              []User
-		sessionid          string
-		reportFilePath     string
-		repStartTime       string
-		repEndTime         string
-		fazReportLayout    int
+		// default dirs(executable-relative or XDG), see defaultPaths
+		paths  = defaultPaths()
+		dbFile = envOr("DSN", filepath.Join(paths.DataDir, "data.db"))
+		// keystore & its key are kept apart(config dir may be copied or backed up)
+		keystoreFile    = envOr("KEYSTORE", filepath.Join(paths.ConfigDir, "keystore.json"))
+		keystoreKeyFile = envOr("KEYSTORE_KEY_FILE", filepath.Join(paths.DataDir, "keystore.key"))
+		mailErr         error
 	)
 
-	fazModel := &fazrep.FazModelJson{}
+	// subcommands
+	args := os.Args[1:]
+	serveMode := false
+	checkMode := false
+	if len(args) > 0 {
+		switch args[0] {
+		case "enqueue":
+			os.Exit(enqueueCmd(args[1:], dbFile))
+		case "requeue":
+			os.Exit(requeueCmd(args[1:], dbFile))
+		case "secrets":
+			os.Exit(secretsCmd(args[1:], keystoreFile, keystoreKeyFile))
+		case "config":
+			// 'config check' uses the same flags as one-shot run
+			if len(args) < 2 || args[1] != "check" {
+				fmt.Fprintln(os.Stderr, "usage: config check [flags]")
+				os.Exit(2)
+			}
+			checkMode = true
+			args = args[2:]
+		case "serve":
+			// 'serve' uses the same flags as one-shot run
+			serveMode = true
+			args = args[1:]
+		}
+	}
 
-	// flags
-	logsDir := flag.String("log-dir", logsPath, "set custom log dir")
+	// flags(precedence: command line, env vars 'FAZ_GET_REPORTS_<FLAG NAME>', 'options' of config file, defaults)
+	configFile := flag.String("config", "", "full path to unified config file(YAML or JSON; default is the first of "+strings.Join(configFileNames, ", ")+" in '-config-dir'), its sections replace legacy data files")
+	configDir := flag.String("config-dir", paths.ConfigDir, "dir of config file & data files(faz-data.json, naumen-data.json, ldap-data.json, users.csv, mailing.json)")
+	fazDataFile := flag.String("faz-data", "", "full path to 'faz-data.json'(default is in '-config-dir')")
+	naumenDataFile := flag.String("naumen-data", "", "full path to 'naumen-data.json'(default is in '-config-dir')")
+	usersFile := flag.String("users-file", "", "full path to 'users.csv' for mode 'csv'(default is in '-config-dir')")
+	reportsDir := flag.String("reports-dir", paths.ReportsDir, "dir of downloaded reports")
+	keystore := flag.String("keystore", keystoreFile, "full path to keystore of credentials(see 'secrets' subcommand)")
+	keystoreKey := flag.String("keystore-key", keystoreKeyFile, "full path to keystore key file(env "+secrets.EnvKey+" overrides it)")
+	ldapDataFile := flag.String("ldap-data", "", "full path to 'ldap-data.json'(default is in '-config-dir')")
+	logsDir := flag.String("log-dir", paths.LogsDir, "set custom log dir")
 	logsToKeep := flag.Int("keep-logs", 30, "set number of logs to keep after rotation")
-	mode := flag.String("mode", "naumen", "set program mode('csv' - use data/users.csv; 'naumen' - work with HD Naumen API & sqlite3 data/data.db &)")
-	mailingOpt := flag.Bool("m", false, "turn the mailing options on(use 'data/mailing.json')")
-	mailingFile := flag.String("mailing-file", mailingFileDefault, "full path to 'mailing.json'")
+	mode := flag.String("mode", "naumen", "set program mode('csv' - use users.csv; 'naumen' - work with HD Naumen API & sqlite3 db; 'naumen-discovery' - find new service calls in HD Naumen, add them to db & work as 'naumen')")
+	ldapOpt := flag.Bool("ldap", false, "validate users(exist & enabled) and resolve their names in AD(use 'ldap-data.json')")
+	mailingOpt := flag.Bool("m", false, "turn the mailing options on(use 'mailing.json')")
+	mailingFile := flag.String("mailing-file", "", "full path to 'mailing.json'(default is in '-config-dir')")
 	hdSolutionText := flag.String("solution-text", "Запрос  исполнен, результат во вложении!", "set solution text for HD Request")
 	dsn := flag.String("dsn", dbFile, "SQLITE3 db file full path")
+	groupReport := flag.String("group-report", groupReportMembers, "report of AD group entry('group:<NAME>', needs '-ldap'): 'members' - one report per member; 'combined' - one report of all members(dataset queries must use '%MEMBERS%')")
+	requesterTz := flag.String("requester-tz", "Local", "time zone of requested periods without explicit time zone(ticket & users.csv), ex.: 'Asia/Almaty'")
+	splitPeriod := flag.String("split-period", "", "split period of user into 'day', 'week' or 'month' slices, every slice is a separate FAZ report(don't split by default)")
+	mergeSlices := flag.Bool("merge-slices", false, "deliver slice reports of user as one archive with manifest(slice files & manifest file by default)")
+	keepReports := flag.Duration("keep-reports", 0, "keep delivered report files for this duration before purge(ex.: '72h'; not delivered files are never purged)")
+	fileTemplate := flag.String("file-template", "", "report file path template relative to reports dir(Go template; vars: User, DisplayName, ShortName, Group, RP, SC, Ticket, Start, End, Profile, Format, Type, RunID, Slice, Vars); default: '"+csvFileTemplate+"'(csv), '"+naumenFileTemplate+"'(naumen)")
+	jobsFile := flag.String("jobs", "", "mode 'csv': jobs file instead of users.csv(JSON('.json'), YAML or csv('.csv')); '-' - stdin, one job per line(JSON object or 'user,start[,end]')")
+	jobUser := flag.String("user", "", "mode 'csv': one job of command line instead of users.csv: account name or 'group:<NAME>'(see '-from' & '-to')")
+	jobFrom := flag.String("from", "", "mode 'csv': period start(or whole period) of '-user' job")
+	jobTo := flag.String("to", "", "mode 'csv': period end of '-user' job(may be omitted)")
+	dryRun := flag.Bool("dry-run", false, "mode 'csv': check users.csv, print parsed jobs and exit(nothing is sent to FAZ, AD groups are not expanded)")
+	interval := flag.Duration("interval", 5*time.Minute, "polling interval of db/Naumen(only for 'serve')")
 
 	flag.Usage = func() {
 		fmt.Println("Version: v0.3.0(11.08.2025)")
+		fmt.Println("Subcommands:")
+		fmt.Println("  config check [flags]\t\tprint effective config(secrets are masked) and check it")
+		fmt.Println("  serve [flags]\t\t\tlong-running mode: process db/Naumen every '-interval'(SIGHUP - reload data files, SIGINT/SIGTERM - graceful shutdown)")
+		fmt.Println("  enqueue [flags] [data$ID ...]\tadd Naumen task ids to db(args, '-f' file or stdin)")
+		fmt.Println("  requeue [flags] [data$ID ...]\treset processed state of Naumen task ids in db")
+		fmt.Println("  secrets [flags] set|get|delete NAME | list\tmanage local encrypted keystore of credentials('keystore:NAME')")
 		fmt.Println("Flags:")
 		flag.PrintDefaults()
 	}
 
-	flag.Parse()
+	flag.CommandLine.Parse(args)
+
+	// env vars first: config dir may be set by env
+	setFlags, err := applyFlagEnv(flag.CommandLine)
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "wrong flag env vars:\n\t%v\n", err)
+		os.Exit(1)
+	}
+	configPath := findConfigFile(*configFile, *configDir)
+	if configPath != "" {
+		rawConfig, err := readConfigFile(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stdout, "%v\n", err)
+			os.Exit(1)
+		}
+		options, _ := rawConfig[sectionOptions].(map[string]any)
+		if err := applyFlagOptions(flag.CommandLine, options, setFlags); err != nil {
+			fmt.Fprintf(os.Stdout, "config file(%s): wrong options:\n\t%v\n", configPath, err)
+			os.Exit(1)
+		}
+	}
+
+	// data files are in config dir unless set explicitly(used for sections missing in config file)
+	config := configSource{
+		ConfigFile:     configPath,
+		FazDataFile:    pathOr(*fazDataFile, *configDir, "faz-data.json"),
+		NaumenDataFile: pathOr(*naumenDataFile, *configDir, "naumen-data.json"),
+		LdapDataFile:   pathOr(*ldapDataFile, *configDir, "ldap-data.json"),
+		MailingFile:    pathOr(*mailingFile, *configDir, "mailing.json"),
+	}
+	usersFilePath := pathOr(*usersFile, *configDir, "users.csv")
+	localJobs := newJobSource(*jobUser, *jobFrom, *jobTo, *jobsFile, usersFilePath)
+
+	// 'naumen-discovery' is 'naumen' mode with finding new service calls first
+	naumenMode := *mode == "naumen" || *mode == "naumen-discovery"
+
+	requesterLocation, reportFileTemplate, optionsErr := checkOptions(*requesterTz, *splitPeriod, *groupReport, *fileTemplate, naumenMode)
+	if optionsErr == nil {
+		optionsErr = checkJobFlags(*jobUser, *jobFrom, *jobTo, *jobsFile)
+	}
+
+	if checkMode {
+		if optionsErr != nil {
+			fmt.Fprintf(os.Stderr, "config is wrong:\n\t%v\n", optionsErr)
+			os.Exit(1)
+		}
+		os.Exit(configCheck(&app{
+			logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+			mode:           *mode,
+			naumenMode:     naumenMode,
+			mailingOpt:     *mailingOpt,
+			ldapOpt:        *ldapOpt,
+			config:         config,
+			secretResolver: &secrets.Resolver{KeystorePath: *keystore, KeyPath: *keystoreKey},
+		}, flag.CommandLine))
+	}
+
+	if *dryRun {
+		os.Exit(dryRunCsv(&app{
+			logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+			mode:              *mode,
+			mailingOpt:        *mailingOpt,
+			config:            config,
+			secretResolver:    &secrets.Resolver{KeystorePath: *keystore, KeyPath: *keystoreKey},
+			jobSource:         localJobs,
+			resultsPath:       *reportsDir,
+			requesterLocation: requesterLocation,
+			fileTemplate:      reportFileTemplate,
+			runId:             time.Now().Format(runIdLayout),
+		}, optionsErr))
+	}
+
+	if serveMode && !naumenMode {
+		fmt.Fprintf(os.Stdout, "mode '%s' is not supported by 'serve', use 'naumen' or 'naumen-discovery'\n", *mode)
+		os.Exit(1)
+	}
 
 	// logging
 	// create log dir
@@ -94,690 +273,178 @@ func main() {
 		fmt.Fprintf(os.Stdout, "failed to create log dir %s:\n\t%v", *logsDir, err)
 		os.Exit(1)
 	}
-	// set current date
-	dateNow := time.Now().Format("02.01.2006")
-	// create log file
-	logFilePath := fmt.Sprintf("%s/%s_%s.log", *logsDir, appName, dateNow)
-	// open log file in append mode
-	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	// open log file in append mode(new file every day)
+	logFile, err := newDailyLogFile(*logsDir, *logsToKeep)
 	if err != nil {
-		fmt.Fprintf(os.Stdout, "failed to open created log file %s:\n\t%v", logFilePath, err)
+		fmt.Fprintf(os.Stdout, "failed to open created log file:\n\t%v", err)
 		os.Exit(1)
 	}
 	defer logFile.Close()
 	// set logger
-	logger := slog.New(slog.NewTextHandler(logFile, nil))
+	// secrets(credentials, session ids) are redacted in log
+	logger := slog.New(secrets.NewRedactHandler(slog.NewTextHandler(logFile, nil)))
 
-	// check if faz-get-report process is running already(exit if is already running)
-	dublicateProcFound, err := helpers.IsAppAlreadyRunning(appName)
+	// check if instance with the same db is running already(exit if is already running)
+	// db is shared state of any mode(tickets, jobs, report files), instances with different db may run simultaneously
+	lockScope, err := filepath.Abs(*dsn)
 	if err != nil {
-		logger.Error("failed to check if there are dublicate procs", slog.Any("ERR", err))
+		logger.Error("failed to get absolute path of db", "DSN", *dsn, slog.Any("ERR", err))
+		os.Exit(1)
 	}
-	if dublicateProcFound {
-		logger.Warn("application is already running, exiting this time")
+	// db dir may not exist yet(XDG data dir)
+	if err := os.MkdirAll(filepath.Dir(*dsn), os.ModePerm); err != nil {
+		logger.Error("failed to create db dir", "DSN", *dsn, slog.Any("ERR", err))
+		os.Exit(1)
+	}
+	instanceLock, staleHolder, err := helpers.AcquireLock(lockScope)
+	if errors.Is(err, helpers.ErrLocked) {
+		logger.Warn("application is already running, exiting this time", slog.Any("LOCK", err))
 		os.Exit(0)
 	}
+	if err != nil {
+		logger.Error("failed to acquire instance lock", slog.Any("ERR", err))
+		os.Exit(1)
+	}
+	defer instanceLock.Release()
+	// os.Exit skips deferred calls, so lock must be released explicitly
+	exit := func(code int) {
+		instanceLock.Release()
+		os.Exit(code)
+	}
+	if staleHolder != nil {
+		logger.Warn("stale instance lock was taken over", "LOCK", instanceLock.Path(), "HOLDER", staleHolder.String())
+	}
 
 	// open db
 	db, err := helpers.OpenDB(*dsn)
 	if err != nil {
 		// mail this error if mailing option is on
 		if *mailingOpt {
-			mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte("failed to open DB file at openDB()"))
+			mailErr = (&app{config: config, secretResolver: &secrets.Resolver{KeystorePath: *keystore, KeyPath: *keystoreKey}}).sendMail("error", "failed to open DB file at openDB()")
 			if mailErr != nil {
 				logger.Warn("failed to send email", slog.Any("ERR", mailErr))
 			}
 		}
 		logger.Error("failed to open DB file", "DSN", *dsn, slog.Any("ERROR", err))
-		os.Exit(1)
+		exit(1)
 	}
 	defer db.Close()
 
 	// define db model instance
-	dbModel := &models.DbModel{DB: db}
-
-	// create map for Naumen RP data(RP, SC, files report)
-	naumenSummary := make(map[string]map[string][]string)
-
-	// starting programm notification
-	startTime := time.Now()
-	logger.Info("Program Started", "APP", appName, "MODE", *mode)
-
-	// making http client for FAZ/HD Naumen request
-	httpClient := vawebwork.NewInsecureClient()
-
-	// READING FAZ DATA FILE
-	fazModelFile, errFile := os.Open(fazModelFilePath)
-	if errFile != nil {
-		// report error
-		errorDataFile := fmt.Sprintf("FAILURE: open FAZ data file:\n\t%v", errFile)
-		// mail this error if mailing option is on
-		if *mailingOpt {
-			mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorDataFile))
-			if mailErr != nil {
-				logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-			}
-		}
-		logger.Error(errorDataFile)
-		os.Exit(1)
+	dbModel, err := models.NewDbModel(db, dbDataTable)
+	if err != nil {
+		logger.Error("failed to define db model", slog.Any("ERROR", err))
+		exit(1)
 	}
-	defer fazModelFile.Close()
 
-	bytefazModel, errRead := io.ReadAll(fazModelFile)
-	if errRead != nil {
-		// report error
-		errorfazModel := fmt.Sprintf("FAILURE: read FAZ data file:\n\t%v", errRead)
-		// mail this error if mailing option is on
-		if *mailingOpt {
-			mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorfazModel))
-			if mailErr != nil {
-				logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-			}
-		}
-		logger.Error(errorfazModel)
-		os.Exit(1)
+	if optionsErr != nil {
+		logger.Error("wrong options", slog.Any("ERR", optionsErr))
+		exit(1)
 	}
 
-	errJsonF := json.Unmarshal(bytefazModel, &fazModel)
-	if errJsonF != nil {
-		// report error
-		errorfazModelJson := fmt.Sprintf("FAILURE: unmarshall FAZ data:\n\t%v", errJsonF)
-		// mail this error if mailing option is on
-		if *mailingOpt {
-			mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorfazModelJson))
-			if mailErr != nil {
-				logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-			}
-		}
-		logger.Error(errorfazModelJson)
-		os.Exit(1)
+	app := &app{
+		logger:            logger,
+		dbModel:           dbModel,
+		dsn:               *dsn,
+		mode:              *mode,
+		naumenMode:        naumenMode,
+		mailingOpt:        *mailingOpt,
+		solutionText:      *hdSolutionText,
+		config:            config,
+		jobSource:         localJobs,
+		ldapOpt:           *ldapOpt,
+		secretResolver:    &secrets.Resolver{KeystorePath: *keystore, KeyPath: *keystoreKey},
+		groupReport:       *groupReport,
+		resultsPath:       *reportsDir,
+		requesterLocation: requesterLocation,
+		splitPeriod:       *splitPeriod,
+		mergeSlices:       *mergeSlices,
+		keepReports:       *keepReports,
+		fileTemplate:      reportFileTemplate,
 	}
 
-	// CREATING REPORTS DIR IF NOT EXIST
-	if err := os.MkdirAll(resultsPath, os.ModePerm); err != nil {
-		// report error
-		errorMkdirResults := fmt.Sprintf("FAILURE: create reports dir(%s):\n\t%v", resultsPath, err)
-		// mail this error if mailing option is on
-		if *mailingOpt {
-			mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorMkdirResults))
-			if mailErr != nil {
-				logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-			}
-		}
-		logger.Error(errorMkdirResults)
-		os.Exit(1)
+	// apply db schema migrations
+	appliedMigrations, err := dbModel.Migrate()
+	if err != nil {
+		app.reportError(fmt.Sprintf("FAILURE: apply db migrations(%s):\n\t%v", *dsn, err))
+		exit(1)
+	}
+	if len(appliedMigrations) != 0 {
+		logger.Info("applied db migrations", slog.Any("MIGRATIONS", appliedMigrations))
 	}
 
-	// different workflows for mode 'db'(default) & 'csv'
-	switch *mode {
-	case "naumen":
-		// TODO: refactor -> vafswork
-		// READING NAUMEN DATA FILE
-		naumenDataFile, errFile := os.Open(naumenDataFilePath)
-		if errFile != nil {
-			// report error
-			errorNaumenData := fmt.Sprintf("FAILURE: open NAUMEN data file:\n\t%v", errFile)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorNaumenData))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorNaumenData)
-			os.Exit(1)
-		}
-		defer naumenDataFile.Close()
-
-		byteNaumenData, errRead := io.ReadAll(naumenDataFile)
-		if errRead != nil {
-			// report error
-			errorNaumenDataRead := fmt.Sprintf("FAILURE: read NAUMEN data file:\n\t%v", errRead)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorNaumenDataRead))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorNaumenDataRead)
-			os.Exit(1)
-		}
-
-		errJsonL := json.Unmarshal(byteNaumenData, &naumenData)
-		if errJsonL != nil {
-			// report error
-			errorNaumenDataJson := fmt.Sprintf("FAILURE: unmarshall NAUMEN data file:\n\t%v", errJsonL)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorNaumenDataJson))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorNaumenDataJson)
-			os.Exit(1)
-		}
+	// starting programm notification
+	startTime := time.Now()
+	logger.Info("Program Started", "APP", appName, "MODE", *mode, "SERVE", serveMode)
 
-		// getting list of unporcessed values in db
-		unprocessedValues, err := dbModel.GetUnprocessedDbValues(dbFile, dbTable, dbValueColumn, dbProcessedColumn)
-		if err != nil {
-			// report error
-			errorUnprocessedValues := fmt.Sprintf("FAILURE: get list of unprocessed values in db(%s):\n\t%v", dbFile, err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorUnprocessedValues))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorUnprocessedValues)
-			os.Exit(1)
-		}
+	// READING DATA FILES
+	if err := app.loadConfig(); err != nil {
+		app.reportError(err.Error())
+		exit(1)
+	}
 
-		// exit program if there are no values to process
-		if len(unprocessedValues) == 0 {
+	if serveMode {
+		app.serve(*interval)
+	} else {
+		err := app.run(context.Background())
+		switch {
+		case errors.Is(err, errNoValues):
 			logger.Warn("no values to process this time, exiting")
-			os.Exit(1)
-		}
-		logger.Info("current unprocessed Naumen data ids", slog.Any("LIST", unprocessedValues))
-
-		// loop to get all users & dates by DB unprocessedValues
-		// TODO: consider goroutine
-		for _, taskId := range unprocessedValues {
-			sumDescription, err := naumen.GetTaskSumDescriptionAndRP(&httpClient, naumenData.NaumenBaseUrl, naumenData.NaumenAccessKey, taskId)
-			if err != nil {
-				// report error
-				errorSumDescription := fmt.Sprintf("FAILURE: get getData from Naumen for '%s':\n\t%v", taskId, err)
-				// mail this error if mailing option is on
-				if *mailingOpt {
-					mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorSumDescription))
-					if mailErr != nil {
-						logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-					}
-				}
-				logger.Error(errorSumDescription)
-				os.Exit(1)
-			}
-			sumDescriptionFound := fmt.Sprintf("found sumDescription of %s(%s):\n\t%v\n", sumDescription[1], sumDescription[0], sumDescription[2])
-			logger.Info(sumDescriptionFound)
-
-			// sumDescription example:
-			// "sumDescription": "<font color=\"#5f5f5f\">Укажите учетную запись: <b>MAMYRBDA, MARCHENM</b>
-			// 	</font><br><font color=\"#5f5f5f\">Укажите дату:: <b>31.07.2025 00:59 - 31.07.2025 19:00</b></font><br>",
-
-			// parse sumDescription for date
-			// we need everyting between <b></b> after 'Укажите дату::'
-			datesPattern := regexp.MustCompile(`.*?Укажите дату:+ +<b>(.*?)<\/b>.*`)
-			// result will be in 2 index of FindStringSubmatch or 'nil' if not found
-			datesSubexpr := datesPattern.FindStringSubmatch(sumDescription[2])
-			if datesSubexpr == nil {
-				// report error
-				errorDatesParsing := fmt.Sprintf("FAILURE: find dates subexpression in sumDescription of %s", taskId)
-				// mail this error if mailing option is on
-				if *mailingOpt {
-					mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorDatesParsing))
-					if mailErr != nil {
-						logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-					}
-				}
-				logger.Error(errorDatesParsing)
-				os.Exit(1)
-			}
-			// next split subexpr for separate dates(start date then end date)
-			datesFound := strings.Split(datesSubexpr[1], " - ")
-			if len(datesFound) == 0 {
-				// report error
-				errorDatesEmpty := fmt.Sprintf("FAILURE: no dates in result of usersSubexpr split(%s)", taskId)
-				// mail this error if mailing option is on
-				if *mailingOpt {
-					mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorDatesEmpty))
-					if mailErr != nil {
-						logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-					}
-				}
-				logger.Error(errorDatesEmpty)
-				os.Exit(1)
-			}
-
-			// next we need to format dates to FAZ format('00:00:01 2024/08/06')
-			for ind, date := range datesFound {
-				// convert string to time.Time(02.11.2024 00:01)
-				tempDate, errT := time.Parse("02.01.2006 15:04", date)
-				if errT != nil {
-					// report error
-					errorParseDateString := fmt.Sprintf("FAILURE: parse date string: %s(%s)", date, taskId)
-					// mail this error if mailing option is on
-					if *mailingOpt {
-						mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorParseDateString))
-						if mailErr != nil {
-							logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-						}
-					}
-					logger.Error(errorParseDateString)
-					os.Exit(1)
-				}
-				// now format time to FAZ format
-				datesFound[ind] = tempDate.Format("15:04:05 2006/01/02")
-			}
-			// fmt.Println(datesFound)
-
-			// parse sumDescription for users
-			// we need everyting between <b></b> after 'Укажите учетную запись:'
-			usersNamesPattern := regexp.MustCompile(`.*?Укажите учетную запись:+ +<b>(.*?)<\/b>.*`)
-			// result will be in 2 index of FindStringSubmatch or 'nil' if not found
-			usersSubexpr := usersNamesPattern.FindStringSubmatch(sumDescription[2])
-			if usersSubexpr == nil {
-				// report error
-				errorUsersParsing := fmt.Sprintf("FAILURE: find users subexpression in sumDescription of %s", taskId)
-				// mail this error if mailing option is on
-				if *mailingOpt {
-					mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorUsersParsing))
-					if mailErr != nil {
-						logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-					}
-				}
-				logger.Error(errorUsersParsing)
-				os.Exit(1)
-			}
-			// next split subexpr for separate users
-			usersFound := strings.Split(usersSubexpr[1], ",")
-			if len(usersFound) == 0 {
-				// report error
-				errorUsersEmpty := fmt.Sprintf("FAILURE: no users in result of usersSubexpr split(%s)!", taskId)
-				// mail this error if mailing option is on
-				if *mailingOpt {
-					mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorUsersEmpty))
-					if mailErr != nil {
-						logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-					}
-				}
-				logger.Error(errorUsersEmpty)
-				os.Exit(1)
-			}
-			// fmt.Println(usersFound)
-
-			// forming users
-			for _, foundUser := range usersFound {
-				user.Username = strings.ToUpper(strings.Trim(foundUser, " "))
-				user.StartDate = datesFound[0]
-				user.EndDate = datesFound[1]
-				user.RP = sumDescription[1]
-				user.DBId = taskId
-				user.ServiceCall = sumDescription[0]
-
-				// append formed user to users list
-				users = append(users, user)
-
-				// fill up summary for Naumen data
-				// EXAMPLE:
-				// <SCID, ex.: serviceCall$725912253>:
-				//	map[<RPID: ex, RP2172655>:
-				//	[<FIRST ELEM iS DATAID, ex: data$3242604> <OTHER ELEM WILL BE DOWNLOADED REPORTS FILE PATHES>
-				naumenSummary[user.ServiceCall] = map[string][]string{user.RP: {taskId}}
-			}
-		}
-	case "csv":
-		// READING USERS FILE
-		usersFile, errFile := os.Open(usersFilePath)
-
-		if errFile != nil {
-			// report error
-			errorCsvFile := fmt.Sprintf("FAILURE: open users file(%s):\n\t%v", usersFile.Name(), errFile)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorCsvFile))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorCsvFile)
-			os.Exit(1)
-		}
-		defer usersFile.Close()
-
-		csvreader := csv.NewReader(usersFile)
-
-		for {
-			row, err := csvreader.Read()
-			if err == io.EOF {
-				break
-			}
-
-			user.Username = strings.ToUpper(row[0])
-			user.StartDate = row[1]
-			user.EndDate = row[2]
-			users = append(users, user)
+			exit(1)
+		case err != nil:
+			app.reportError(err.Error())
+			exit(1)
 		}
 	}
 
-	//fmt.Printf("%v", users)
+	// count & print estimated time
+	endTime := time.Now()
+	logger.Info("Program's job is Done", slog.Any("estimated time(sec)", endTime.Sub(startTime).Seconds()))
 
-	// GETTING FAZ SESSION ID
-	logger.Info("getting FAZ session id")
-	sessionid, errS := fazModel.GetSessionid(&httpClient, fazModel.FazUrl, fazModel.ApiUser, fazModel.ApiUserPass)
-	if errS != nil {
-		// report error
-		errorFazSessionid := fmt.Sprintf("FAILURE: get FAZ sessionid\n\t%v", errS)
-		// mail this error if mailing option is on
-		if *mailingOpt {
-			mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorFazSessionid))
-			if mailErr != nil {
-				logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-			}
-		}
-		logger.Error(errorFazSessionid)
-		os.Exit(1)
+	// close logfile and rotate logs
+	logFile.Close()
+
+	if err := vafswork.RotateFilesByMtime(*logsDir, *logsToKeep); err != nil {
+		fmt.Fprintf(os.Stdout, "failure to rotate logs:\n\t%s", err)
 	}
+}
 
-	// GETTING FAZ REPORT LAYOUT
-	fazReportLayout, errLayout := fazModel.GetFazReportLayout(&httpClient, fazModel.FazUrl, sessionid, fazModel.FazAdom, fazModel.FazReportName)
+// check options which don't need config sections: requester time zone, split period, group report mode & report file template
+func checkOptions(requesterTz, splitPeriod, groupReport, fileTemplate string, naumenMode bool) (*time.Location, *template.Template, error) {
+	requesterLocation, err := time.LoadLocation(requesterTz)
 	if err != nil {
-		// report error
-		errorFazRepLayout := fmt.Sprintf("FAILURE: get FAZ report layout:\n\t%v", errLayout)
-		// mail this error if mailing option is on
-		if *mailingOpt {
-			mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorFazRepLayout))
-			if mailErr != nil {
-				logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-			}
-		}
-		logger.Error(errorFazRepLayout)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("wrong requester time zone(%s):\n\t%v", requesterTz, err)
 	}
 
-	// STARTING GETTING REPORT LOOP
-	logger.Info("Users data to process in FAZ:")
-	for _, user := range users {
-		logger.Info("processing now", slog.Any("USR", user))
+	if _, err := (period.Period{}).Split(splitPeriod); err != nil {
+		return nil, nil, fmt.Errorf("wrong split period:\n\t%v", err)
 	}
 
-	for _, user := range users {
-		logger.Info("getting report job", "USR", user.Username)
-
-		// UPDATING DATASETS QUERY
-		errUpdDataset := fazModel.UpdateDatasets(&httpClient, fazModel.FazUrl, sessionid, fazModel.FazAdom, user.Username, fazModel.FazDatasets)
-		if errUpdDataset != nil {
-			// report error
-			errorfazModelsetUpd := fmt.Sprintf("FAILURE: to update FAZ datasets:\n\t%v", errUpdDataset)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorfazModelsetUpd))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorfazModelsetUpd)
-			os.Exit(1)
-		}
-
-		// STARTING REPORT
-		logger.Info("started running FAZ report job", "USR", user.Username)
-
-		repId, err := fazModel.StartReport(&httpClient, fazModel.FazUrl, fazModel.FazAdom, fazModel.FazDevice, sessionid, user.StartDate, user.EndDate, fazReportLayout)
-		if err != nil {
-			// report error
-			errorFazReportStart := fmt.Sprintf("FAILURE: to start FAZ report:\n\t%v", err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorFazReportStart))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorFazReportStart)
-			os.Exit(1)
-		}
-
-		// DOWNLOADING PDF REPORT
-		logger.Info("started downloading report", "USR", user.Username)
-
-		repData, err := fazModel.DownloadPdfReport(&httpClient, fazModel.FazUrl, fazModel.FazAdom, sessionid, repId)
-		if err != nil {
-			// report error
-			errorFazReportDownload := fmt.Sprintf("FAILURE: dowonload FAZ report:\n\t%v", err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorFazReportDownload))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorFazReportDownload)
-			os.Exit(1)
-		}
-
-		// GETTING DATES FOR REPORT FILE
-		tempTime, err := time.Parse("15:04:05 2006/01/02", user.StartDate)
-		if err != nil {
-			// report error
-			errorUserStartTimeParse := fmt.Sprintf("FAILURE: to Parse User(%v) Start Time(%v):\n\t%v", user, tempTime, err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorUserStartTimeParse))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorUserStartTimeParse)
-			os.Exit(1)
-		}
-		repStartTime = tempTime.Format("02-01-2006-T-15-04-05")
-
-		tempTime, err = time.Parse("15:04:05 2006/01/02", user.EndDate)
-		if err != nil {
-			// report error
-			errorUserEndTimeParse := fmt.Sprintf("FAILURE: to Parse User(%v) End Time(%v):\n\t%v", user, tempTime, err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorUserEndTimeParse))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorUserEndTimeParse)
-			os.Exit(1)
-		}
-		repEndTime = tempTime.Format("02-01-2006-T-15-04-05")
-
-		// SAVING REPORT TO FILE
-
-		// decoding base64 data to []byte
-		dec, err := base64.StdEncoding.DecodeString(repData)
-		if err != nil {
-			// report error
-			errorFazReportDecode := fmt.Sprintf("FAILURE: to Decode Report Data(%s):\n\t%v", repData, err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorFazReportDecode))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorFazReportDecode)
-			os.Exit(1)
-		}
-
-		// forming report file full path
-		reportFilePath = fmt.Sprintf("%s/%s_%s_%s.zip", resultsPath, user.Username, repStartTime, repEndTime)
-		// if mode == 'naumen' save to user.RP subdir of resultsPath
-		if *mode == "naumen" {
-			// creating Report dir for RP: 'Reports/RP***'
-			if err := os.MkdirAll(resultsPath+"/"+user.RP, os.ModePerm); err != nil {
-				// report error
-				errorMkdirReportRP := fmt.Sprintf("FAILURE: create reports dir with RP(%s):\n\t%v", user.RP, err)
-				// mail this error if mailing option is on
-				if *mailingOpt {
-					mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorMkdirReportRP))
-					if mailErr != nil {
-						logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-					}
-				}
-				logger.Error(errorMkdirReportRP)
-				os.Exit(1)
-			}
-
-			reportFilePath = fmt.Sprintf("%s/%s/%s.zip", resultsPath, user.RP, user.Username)
-		}
-
-		// create empty report file(full path)
-		file, err := os.Create(reportFilePath)
-		if err != nil {
-			// report error
-			errorCreateReportBlankFile := fmt.Sprintf("FAILURE: to Create Report Blank File(%s):\n\t%v", reportFilePath, err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorCreateReportBlankFile))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorCreateReportBlankFile)
-			os.Exit(1)
-		}
-		defer file.Close()
-
-		// write decoded data to report file
-		if _, err := file.Write(dec); err != nil {
-			// report error
-			errorWriteReportData := fmt.Sprintf("FAILURE: to Write Report Data to File(%s):\n\t%v", reportFilePath, err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorWriteReportData))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorWriteReportData)
-			os.Exit(1)
-		}
-		if err := file.Sync(); err != nil {
-			// report error
-			errorSyncReportData := fmt.Sprintf("FAILURE: to Sync Written Report File(%s):\n\t%v", reportFilePath, err)
-			// mail this error if mailing option is on
-			if *mailingOpt {
-				mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorSyncReportData))
-				if mailErr != nil {
-					logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-				}
-			}
-			logger.Error(errorSyncReportData)
-			os.Exit(1)
-		}
-
-		// fill up summary for Naumen data with downloaded reports file pathes
-		if *mode == "naumen" {
-			naumenSummary[user.ServiceCall][user.RP] = append(naumenSummary[user.ServiceCall][user.RP], reportFilePath)
-		}
-
-		logger.Info("finished getting report job", "USR", user.Username, "RP", user.RP)
+	if groupReport != groupReportMembers && groupReport != groupReportCombined {
+		return nil, nil, fmt.Errorf("wrong group report mode(%s), must be 'members' or 'combined'", groupReport)
 	}
 
-	// if mode 'naumen' - attach collected reports, close ticket(set wait for acceptance)
-	if *mode == "naumen" {
-		logger.Info("Collected task data for Naumen RPs:")
-		for sc, val := range naumenSummary {
-			logger.Info("-", slog.Any("SC", sc), slog.Any("VAL", val))
-		}
-
-		// take responsibility on request, attach files and set acceptance
-		for sc := range naumenSummary {
-			// take responsibility on request
-			logger.Info("started take responsibility on Naumen ticket", "SC", sc)
-
-			errT := naumen.TakeSCResponsibility(&httpClient, naumenData.NaumenBaseUrl, naumenData.NaumenAccessKey, sc)
-			if errT != nil {
-				// report error
-				errorTakeResp := fmt.Sprintf("FAILURE: take responsibility on Naumen ticket(%s):\n\t%v", naumenSummary[sc], errT)
-				// mail this error if mailing option is on
-				if *mailingOpt {
-					mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorTakeResp))
-					if mailErr != nil {
-						logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-					}
-				}
-				logger.Error(errorTakeResp)
-				os.Exit(1)
-			}
+	reportFileTemplate, err := parseFileTemplate(fileTemplate, naumenMode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("wrong report file template:\n\t%v", err)
+	}
 
-			// logger.Printf("FINISHED: take responsibility on Naumen ticket: %s\n", naumenSummary[sc])
-
-			// attach files to RP and set acceptance
-			for rp, files := range naumenSummary[sc] {
-				logger.Info("started attaching files to ticket and set acceptance", "RP", rp)
-
-				// for files skip 0 index, because it's dataID
-				errA := naumen.AttachFilesAndSetAcceptance(
-					&httpClient,
-					naumenData.NaumenBaseUrl,
-					naumenData.NaumenAccessKey,
-					sc,
-					*hdSolutionText,
-					files[1:])
-				if errA != nil {
-					// report error
-					errorAFSA := fmt.Sprintf("FAILURE: attaching files to ticket and set acceptance(%s):\n\t%v", rp, errA)
-					// mail this error if mailing option is on
-					if *mailingOpt {
-						mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorAFSA))
-						if mailErr != nil {
-							logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-						}
-					}
-					logger.Error(errorAFSA)
-					os.Exit(1)
-				}
-
-				logger.Info("finished take responsibility, attach reports and set acceptance on Naumen ticket", "RP", rp)
-				logger.Info("started update db with success result", "VAL", naumenSummary[sc][rp][0])
-
-				errU := dbModel.UpdDbValue(
-					dbFile, dbTable, dbValueColumn, dbProcessedColumn, dbProcessedDateColumn,
-					naumenSummary[sc][rp][0], 1)
-				if errU != nil {
-					// report error
-					errorDbUpd := fmt.Sprintf("FAILURE: update value(%s) to result(%v):\n\t%v", naumenSummary[sc][rp][0], 1, errU)
-					// mail this error if mailing option is on
-					if *mailingOpt {
-						mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "error", appName, []byte(errorDbUpd))
-						if mailErr != nil {
-							logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-						}
-					}
-					logger.Error(errorDbUpd)
-					os.Exit(1)
-				}
-
-				// report success
-				reportDbUPD := fmt.Sprintf("FINISHED: processing, including DBUpd: %s\n", rp)
-				// mail this error if mailing option is on
-				if *mailingOpt {
-					mailErr = mailing.SendPlainEmailWoAuth(*mailingFile, "report", appName, []byte(reportDbUPD))
-					if mailErr != nil {
-						logger.Warn("failed to send email", slog.Any("ERR", mailErr))
-					}
-				}
-				logger.Info(reportDbUPD)
-			}
-		}
+	return requesterLocation, reportFileTemplate, nil
+}
 
-		// clean reports dir if mode 'naumen'
-		dirToRemove := filepath.Dir(reportFilePath)
-		logger.Info("cleaning reports dir", "dir", dirToRemove)
-		if err = os.RemoveAll(dirToRemove); err != nil {
-			logger.Info("failed cleaning reports dir", "dir", dirToRemove)
+// '-user' job needs '-from', '-jobs' & '-user' are exclusive
+func checkJobFlags(user, from, to, jobsFile string) error {
+	if user == "" {
+		if from != "" || to != "" {
+			return fmt.Errorf("'-from' & '-to' are used only with '-user'")
 		}
-
+		return nil
 	}
-
-	// count & print estimated time
-	endTime := time.Now()
-	logger.Info("Program's job is Done", slog.Any("estimated time(sec)", endTime.Sub(startTime).Seconds()))
-
-	// close logfile and rotate logs
-	logFile.Close()
-
-	if err := vafswork.RotateFilesByMtime(*logsDir, *logsToKeep); err != nil {
-		fmt.Fprintf(os.Stdout, "failure to rotate logs:\n\t%s", err)
+	if jobsFile != "" {
+		return fmt.Errorf("'-user' & '-jobs' can't be used together")
+	}
+	if from == "" {
+		return fmt.Errorf("'-user' needs '-from'")
 	}
+	return nil
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	models "github.com/slayerjk/faz-get-reports/internal/models"
+)
+
+// register generated report files in db('Outputs' table)
+//
+// db errors are only logged: file lifecycle must not break report delivery
+func (a *app) outputsGenerated(ticket string, paths ...string) {
+	for _, path := range paths {
+		if err := a.dbModel.AddOutput(path, ticket); err != nil {
+			a.logger.Warn("failed to register report file", "FILE", path, slog.Any("ERR", err))
+		}
+	}
+}
+
+// mark report files as delivered, they are retained for '-keep-reports' and purged after
+func (a *app) outputsDelivered(paths []string) {
+	if err := a.dbModel.SetOutputsDelivered(paths, time.Now().Add(a.keepReports)); err != nil {
+		a.logger.Warn("failed to mark report files as delivered", slog.Any("FILES", paths), slog.Any("ERR", err))
+	}
+}
+
+// delete delivered report files which retention is over(not delivered files are never deleted)
+//
+// empty report dirs(ex.: 'Reports/RP***') are removed too
+func (a *app) purgeOutputs() {
+	expired, err := a.dbModel.GetExpiredOutputs(time.Now())
+	if err != nil {
+		a.logger.Warn("failed to get expired report files", slog.Any("ERR", err))
+		return
+	}
+
+	a.removeOutputs(expired)
+}
+
+// delete not delivered report files of previous(failed) runs of tickets, whose reports are generated again in this run
+//
+// called only after new files are registered, so until then previous files are kept(ex.: FAZ or helpdesk is still unavailable)
+func (a *app) purgePreviousOutputs(tickets *naumenTickets) {
+	for _, sc := range tickets.ServiceCalls() {
+		for _, ticket := range tickets.Tickets(sc) {
+			a.purgeTicketOutputs(ticket.DBId, ticket.Files)
+		}
+	}
+}
+
+// delete not delivered report files of ticket except current ones
+func (a *app) purgeTicketOutputs(ticket string, current []string) {
+	outputs, err := a.dbModel.GetTicketOutputs(ticket)
+	if err != nil {
+		a.logger.Warn("failed to get previous report files of ticket", "VAL", ticket, slog.Any("ERR", err))
+		return
+	}
+
+	keep := make(map[string]bool, len(current))
+	for _, path := range current {
+		keep[path] = true
+	}
+	previous := make([]models.Output, 0, len(outputs))
+	for _, output := range outputs {
+		if !keep[output.Path] {
+			previous = append(previous, output)
+		}
+	}
+
+	a.removeOutputs(previous)
+}
+
+// delete report files & mark them as purged, remove dir of file if it's empty now
+func (a *app) removeOutputs(outputs []models.Output) {
+	for _, output := range outputs {
+		if err := os.Remove(output.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			a.logger.Warn("failed to purge report file", "FILE", output.Path, slog.Any("ERR", err))
+			continue
+		}
+
+		if err := a.dbModel.SetOutputPurged(output.ID); err != nil {
+			a.logger.Warn("failed to mark report file as purged", "FILE", output.Path, slog.Any("ERR", err))
+			continue
+		}
+		a.logger.Info("purged report file", "FILE", output.Path, "VAL", output.Ticket)
+
+		// remove dir if it's empty now(error if it's not empty)
+		if dir := filepath.Dir(output.Path); filepath.Clean(dir) != filepath.Clean(a.resultsPath) {
+			os.Remove(dir)
+		}
+	}
+}
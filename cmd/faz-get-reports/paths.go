@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	vafswork "github.com/slayerjk/go-vafswork"
+)
+
+// prefix of env vars overriding flag defaults, ex.: FAZ_GET_REPORTS_CONFIG_DIR
+var envPrefix = strings.ToUpper(strings.ReplaceAll(appName, "-", "_")) + "_"
+
+// default dirs of program
+type appPaths struct {
+	// data files: faz-data.json, naumen-data.json, users.csv, mailing.json
+	ConfigDir string
+	// db file
+	DataDir    string
+	ReportsDir string
+	LogsDir    string
+}
+
+// default dirs: executable-relative if '<EXE DIR>/data' exists(legacy layout),
+// XDG base dirs otherwise($XDG_CONFIG_HOME, $XDG_DATA_HOME, $XDG_STATE_HOME or their defaults)
+func defaultPaths() appPaths {
+	exePath := vafswork.GetExePath()
+
+	if info, err := os.Stat(filepath.Join(exePath, "data")); err == nil && info.IsDir() {
+		return appPaths{
+			ConfigDir:  filepath.Join(exePath, "data"),
+			DataDir:    filepath.Join(exePath, "data"),
+			ReportsDir: filepath.Join(exePath, "Reports"),
+			LogsDir:    filepath.Join(exePath, "logs_"+appName),
+		}
+	}
+
+	dataDir := xdgDir("XDG_DATA_HOME", ".local/share")
+
+	return appPaths{
+		ConfigDir:  xdgDir("XDG_CONFIG_HOME", ".config"),
+		DataDir:    dataDir,
+		ReportsDir: filepath.Join(dataDir, "Reports"),
+		LogsDir:    filepath.Join(xdgDir("XDG_STATE_HOME", ".local/state"), "logs"),
+	}
+}
+
+// '<XDG DIR>/faz-get-reports': env var or '<HOME>/<homeSubdir>'(OS config dir on Windows)
+func xdgDir(env, homeSubdir string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName)
+	}
+
+	if filepath.Separator == '\\' {
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, appName)
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// nowhere else to go
+		return filepath.Join(vafswork.GetExePath(), appName)
+	}
+
+	return filepath.Join(home, homeSubdir, appName)
+}
+
+// value of env var '<envPrefix><name>' or def if it's not set
+func envOr(name, def string) string {
+	if value, ok := os.LookupEnv(envPrefix + name); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// path of file in dir if path is not set
+func pathOr(path, dir, name string) string {
+	if path != "" {
+		return path
+	}
+	return filepath.Join(dir, name)
+}
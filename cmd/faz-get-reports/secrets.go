@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/slayerjk/faz-get-reports/internal/secrets"
+)
+
+// subcommand 'secrets': manage local encrypted keystore(secret references 'keystore:<NAME>')
+//
+//	secrets set NAME   - value is read from stdin(first line), so it's not kept in shell history
+//	secrets get NAME   - print value
+//	secrets list       - print names
+//	secrets delete NAME
+func secretsCmd(args []string, keystorePath, keyPath string) int {
+	flags := flag.NewFlagSet("secrets", flag.ContinueOnError)
+	keystoreFile := flags.String("keystore", keystorePath, "keystore file full path")
+	keyFile := flags.String("keystore-key", keyPath, "keystore key file full path(env "+secrets.EnvKey+" overrides it)")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s secrets [flags] set|get|delete NAME | list\n", appName)
+		fmt.Fprintln(flags.Output(), "Value of 'set' is read from stdin(first line). Use it in data files as 'keystore:NAME'.")
+		fmt.Fprintln(flags.Output(), "Flags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	action, name := flags.Arg(0), flags.Arg(1)
+	if action == "" || (action != "list" && name == "") {
+		flags.Usage()
+		return 2
+	}
+
+	keystore, err := secrets.OpenKeystore(*keystoreFile, *keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open keystore:\n\t%v\n", err)
+		return 1
+	}
+
+	switch action {
+	case "set":
+		value, err := readSecret(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read secret from stdin:\n\t%v\n", err)
+			return 1
+		}
+		if err := keystore.Set(name, value); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to set secret:\n\t%v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stdout, "saved: %s(use 'keystore:%s')\n", name, name)
+	case "get":
+		value, err := keystore.Get(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to get secret:\n\t%v\n", err)
+			return 1
+		}
+		fmt.Fprintln(os.Stdout, value)
+	case "delete":
+		if err := keystore.Delete(name); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to delete secret:\n\t%v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stdout, "deleted: %s\n", name)
+	case "list":
+		for _, secretName := range keystore.Names() {
+			fmt.Fprintln(os.Stdout, secretName)
+		}
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	return 0
+}
+
+// first line of reader without new line, empty value is an error
+func readSecret(reader io.Reader) (string, error) {
+	line, err := bufio.NewReader(reader).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return "", secrets.ErrEmpty
+	}
+
+	return value, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// long-running mode: run processing cycle every interval
+//
+// errors of a cycle(ex.: FAZ/Naumen outage) are reported and next cycle is run as usual;
+// SIGHUP - reload data files; SIGINT/SIGTERM - finish in-flight report job and exit
+func (a *app) serve(interval time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	a.logger.Info("serve started", "INTERVAL", interval.String())
+
+	// last reported error, the same error isn't mailed on every cycle
+	lastErr := ""
+
+	for {
+		a.cycle(ctx, &lastErr)
+
+		if !a.wait(ctx, ticker, reload) {
+			a.logger.Info("serve stopped by signal")
+			return
+		}
+	}
+}
+
+// wait for next cycle, reloading data files on SIGHUP
+//
+// returns false on shutdown
+func (a *app) wait(ctx context.Context, ticker *time.Ticker, reload <-chan os.Signal) bool {
+	for {
+		// shutdown has priority over pending tick
+		if ctx.Err() != nil {
+			return false
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-reload:
+			a.reloadConfig()
+		case <-ticker.C:
+			// SIGHUP received during cycle must be applied before next one
+			select {
+			case <-reload:
+				a.reloadConfig()
+			default:
+			}
+			return ctx.Err() == nil
+		}
+	}
+}
+
+// reload data files, keep previous ones on error
+func (a *app) reloadConfig() {
+	a.logger.Info("reloading data files")
+	if err := a.loadConfig(); err != nil {
+		a.reportError(fmt.Sprintf("FAILURE: reload data files, keep using previous ones:\n\t%v", err))
+		return
+	}
+	a.logger.Info("data files reloaded")
+}
+
+// one serve cycle, never exits program
+func (a *app) cycle(ctx context.Context, lastErr *string) {
+	// unexpected panic of a cycle must not stop serve
+	defer func() {
+		if r := recover(); r != nil {
+			a.reportError(fmt.Sprintf("FAILURE: panic in processing cycle:\n\t%v", r))
+		}
+	}()
+
+	startTime := time.Now()
+	err := a.run(ctx)
+
+	switch {
+	case err == nil:
+		*lastErr = ""
+		a.logger.Info("processing cycle is done", slog.Any("estimated time(sec)", time.Since(startTime).Seconds()))
+	case errors.Is(err, errNoValues):
+		*lastErr = ""
+		a.logger.Info("no values to process this time")
+	case errors.Is(err, errInterrupted):
+		a.logger.Warn("processing cycle was interrupted, unprocessed values will be processed after restart")
+	case err.Error() == *lastErr:
+		a.logger.Error(err.Error(), "REPEATED", true)
+	default:
+		*lastErr = err.Error()
+		a.reportError(err.Error())
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// name of manifest in merged archive
+const manifestName = "manifest.json"
+
+// which slice of period covers which report file
+type reportManifest struct {
+	Username string          `json:"username"`
+	Ticket   string          `json:"ticket,omitempty"`
+	Start    string          `json:"start"`
+	End      string          `json:"end"`
+	Split    string          `json:"split"`
+	Reports  []manifestEntry `json:"reports"`
+}
+
+type manifestEntry struct {
+	File     string `json:"file"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	FazStart string `json:"faz-start"`
+	FazEnd   string `json:"faz-end"`
+}
+
+// deliver slice reports of user together: merged archive with manifest('-merge-slices')
+// or slice files plus manifest file('<REPORT FILE NAME>_manifest.json')
+//
+// returns files to deliver
+func (a *app) packSlices(user User, manifest reportManifest, sliceFiles []string) ([]string, error) {
+	manifestData, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("FAILURE: marshal manifest of user(%s):\n\t%v", user.Username, err)
+	}
+
+	// name of whole period report
+	reportFilePath, err := a.reportFilePath(user, false, user.reportFormats()[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.mergeSlices {
+		manifestPath := uniquePath(strings.TrimSuffix(reportFilePath, filepath.Ext(reportFilePath)) + "_manifest.json")
+		if err := os.WriteFile(manifestPath, manifestData, 0644); err != nil {
+			return nil, fmt.Errorf("FAILURE: write manifest(%s):\n\t%v", manifestPath, err)
+		}
+		return append(sliceFiles, manifestPath), nil
+	}
+
+	archivePath := reportFilePath
+
+	if err := mergeArchive(archivePath, manifestData, sliceFiles); err != nil {
+		return nil, fmt.Errorf("FAILURE: merge reports of user(%s) to archive(%s):\n\t%v", user.Username, archivePath, err)
+	}
+
+	for _, sliceFile := range sliceFiles {
+		if err := os.Remove(sliceFile); err != nil {
+			a.logger.Warn("failed to remove merged slice report", "FILE", sliceFile, slog.Any("ERR", err))
+		}
+	}
+
+	a.logger.Info("merged slice reports", "USR", user.Username, "FILE", archivePath, "SLICES", len(sliceFiles))
+
+	return []string{archivePath}, nil
+}
+
+// create zip archive of files(by base name) & manifest
+func mergeArchive(archivePath string, manifestData []byte, files []string) error {
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	writer := zip.NewWriter(archive)
+
+	manifestWriter, err := writer.CreateHeader(&zip.FileHeader{Name: manifestName, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err := manifestWriter.Write(manifestData); err != nil {
+		return err
+	}
+
+	for _, filePath := range files {
+		if err := addArchiveFile(writer, filePath); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return archive.Sync()
+}
+
+func addArchiveFile(writer *zip.Writer, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// slice reports are zip already, store them as is
+	fileWriter, err := writer.CreateHeader(&zip.FileHeader{Name: filepath.Base(filePath), Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fileWriter, file)
+	return err
+}
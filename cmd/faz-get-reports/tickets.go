@@ -0,0 +1,96 @@
+package main
+
+// Naumen ticket to deliver: db value(task id), its service call & RP and downloaded reports
+type naumenTicket struct {
+	DBId        string
+	ServiceCall string
+	RP          string
+	Users       []string
+	Files       []string
+}
+
+// Naumen tickets of a run grouped by service call, order of adding is kept
+type naumenTickets struct {
+	serviceCalls []string
+	// tickets of service call
+	bySC map[string][]*naumenTicket
+	// ticket of db value
+	byDBId map[string]*naumenTicket
+}
+
+func newNaumenTickets() *naumenTickets {
+	return &naumenTickets{
+		bySC:   make(map[string][]*naumenTicket),
+		byDBId: make(map[string]*naumenTicket),
+	}
+}
+
+// add ticket of db value(existing ticket is returned if db value is added already)
+func (t *naumenTickets) add(dbId, serviceCall, rp string) *naumenTicket {
+	if ticket, ok := t.byDBId[dbId]; ok {
+		return ticket
+	}
+
+	ticket := &naumenTicket{DBId: dbId, ServiceCall: serviceCall, RP: rp}
+
+	if _, ok := t.bySC[serviceCall]; !ok {
+		t.serviceCalls = append(t.serviceCalls, serviceCall)
+	}
+	t.bySC[serviceCall] = append(t.bySC[serviceCall], ticket)
+	t.byDBId[dbId] = ticket
+
+	return ticket
+}
+
+// add user to ticket of db value
+func (t *naumenTickets) addUser(dbId, username string) {
+	if ticket, ok := t.byDBId[dbId]; ok {
+		ticket.Users = append(ticket.Users, username)
+	}
+}
+
+// add downloaded report files to ticket of db value
+func (t *naumenTickets) addFiles(dbId string, files ...string) {
+	if ticket, ok := t.byDBId[dbId]; ok {
+		ticket.Files = append(ticket.Files, files...)
+	}
+}
+
+// service calls in order of adding
+func (t *naumenTickets) ServiceCalls() []string {
+	return t.serviceCalls
+}
+
+// tickets of service call in order of adding
+func (t *naumenTickets) Tickets(serviceCall string) []*naumenTicket {
+	return t.bySC[serviceCall]
+}
+
+// remove ticket of db value(ex.: it's failed)
+func (t *naumenTickets) remove(dbId string) {
+	ticket, ok := t.byDBId[dbId]
+	if !ok {
+		return
+	}
+	delete(t.byDBId, dbId)
+
+	scTickets := t.bySC[ticket.ServiceCall]
+	for ind, scTicket := range scTickets {
+		if scTicket == ticket {
+			scTickets = append(scTickets[:ind], scTickets[ind+1:]...)
+			break
+		}
+	}
+	if len(scTickets) != 0 {
+		t.bySC[ticket.ServiceCall] = scTickets
+		return
+	}
+
+	delete(t.bySC, ticket.ServiceCall)
+	for ind, sc := range t.serviceCalls {
+		if sc == ticket.ServiceCall {
+			t.serviceCalls = append(t.serviceCalls[:ind], t.serviceCalls[ind+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// files & users of all tickets of service call, as they are delivered
+func serviceCallData(tickets *naumenTickets, serviceCall string) (rps, users, files []string) {
+	for _, ticket := range tickets.Tickets(serviceCall) {
+		rps = append(rps, ticket.RP)
+		users = append(users, ticket.Users...)
+		files = append(files, ticket.Files...)
+	}
+	return rps, users, files
+}
+
+func TestNaumenTickets(t *testing.T) {
+	tickets := newNaumenTickets()
+
+	// two RPs of one service call, several users of RP
+	tickets.add("data$1", "serviceCall$10", "RP1")
+	tickets.add("data$2", "serviceCall$10", "RP2")
+	tickets.add("data$3", "serviceCall$20", "RP3")
+
+	// db value is added once
+	if ticket := tickets.add("data$1", "serviceCall$99", "RP99"); ticket.ServiceCall != "serviceCall$10" || ticket.RP != "RP1" {
+		t.Errorf("add() of existing db value = %+v, want ticket of serviceCall$10", ticket)
+	}
+
+	tickets.addUser("data$1", "USER1")
+	tickets.addUser("data$1", "USER2")
+	tickets.addUser("data$2", "USER3")
+	tickets.addUser("data$3", "USER4")
+	tickets.addUser("data$3", "USER5")
+	tickets.addUser("data$404", "USER6")
+
+	tickets.addFiles("data$1", "USER1.pdf")
+	tickets.addFiles("data$1", "USER2.pdf", "USER2.csv")
+	tickets.addFiles("data$2", "USER3.pdf")
+	tickets.addFiles("data$3", "USER4.pdf")
+	tickets.addFiles("data$3", "USER5.pdf")
+	tickets.addFiles("data$404", "USER6.pdf")
+
+	if got, want := tickets.ServiceCalls(), []string{"serviceCall$10", "serviceCall$20"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ServiceCalls() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		serviceCall string
+		rps         []string
+		users       []string
+		files       []string
+	}{
+		{
+			serviceCall: "serviceCall$10",
+			rps:         []string{"RP1", "RP2"},
+			users:       []string{"USER1", "USER2", "USER3"},
+			files:       []string{"USER1.pdf", "USER2.pdf", "USER2.csv", "USER3.pdf"},
+		},
+		{
+			serviceCall: "serviceCall$20",
+			rps:         []string{"RP3"},
+			users:       []string{"USER4", "USER5"},
+			files:       []string{"USER4.pdf", "USER5.pdf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serviceCall, func(t *testing.T) {
+			rps, users, files := serviceCallData(tickets, tt.serviceCall)
+			if !reflect.DeepEqual(rps, tt.rps) {
+				t.Errorf("RPs = %v, want %v", rps, tt.rps)
+			}
+			if !reflect.DeepEqual(users, tt.users) {
+				t.Errorf("users = %v, want %v", users, tt.users)
+			}
+			if !reflect.DeepEqual(files, tt.files) {
+				t.Errorf("files = %v, want %v", files, tt.files)
+			}
+		})
+	}
+}
+
+func TestNaumenTicketsRemove(t *testing.T) {
+	tickets := newNaumenTickets()
+	tickets.add("data$1", "serviceCall$10", "RP1")
+	tickets.add("data$2", "serviceCall$10", "RP2")
+	tickets.add("data$3", "serviceCall$20", "RP3")
+	tickets.addFiles("data$1", "USER1.pdf")
+	tickets.addFiles("data$2", "USER2.pdf")
+	tickets.addFiles("data$3", "USER3.pdf")
+
+	// failed RP doesn't drop other RPs of service call
+	tickets.remove("data$1")
+	if rps, _, files := serviceCallData(tickets, "serviceCall$10"); !reflect.DeepEqual(rps, []string{"RP2"}) || !reflect.DeepEqual(files, []string{"USER2.pdf"}) {
+		t.Errorf("serviceCall$10 after remove = %v %v, want [RP2] [USER2.pdf]", rps, files)
+	}
+
+	// files of removed ticket are not added anymore
+	tickets.addFiles("data$1", "late.pdf")
+	if _, _, files := serviceCallData(tickets, "serviceCall$10"); !reflect.DeepEqual(files, []string{"USER2.pdf"}) {
+		t.Errorf("serviceCall$10 files = %v, want [USER2.pdf]", files)
+	}
+
+	// service call without tickets is removed
+	tickets.remove("data$3")
+	tickets.remove("data$404")
+	if got, want := tickets.ServiceCalls(), []string{"serviceCall$10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceCalls() = %v, want %v", got, want)
+	}
+	if got := tickets.Tickets("serviceCall$20"); len(got) != 0 {
+		t.Errorf("Tickets(serviceCall$20) = %v, want none", got)
+	}
+
+	// removed db value may be added again
+	tickets.add("data$3", "serviceCall$20", "RP3")
+	if got, want := tickets.ServiceCalls(), []string{"serviceCall$10", "serviceCall$20"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceCalls() = %v, want %v", got, want)
+	}
+}
@@ -3,15 +3,20 @@ module github.com/slayerjk/faz-get-reports
 go 1.22.3
 
 require (
+	github.com/go-asn1-ber/asn1-ber v1.5.5
+	github.com/go-ldap/ldap/v3 v3.4.8
 	github.com/ncruces/go-sqlite3 v0.20.0
 	github.com/slayerjk/go-hd-naumen-api v0.0.1
-	github.com/slayerjk/go-mailing v0.0.1
 	github.com/slayerjk/go-vafswork v0.0.3
-	github.com/slayerjk/go-vawebwork v0.0.1
+	golang.org/x/net v0.30.0
+	golang.org/x/sys v0.26.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/Azure/go-ntlmssp v0.0.0-20221128193559-754e69321358 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 	github.com/ncruces/julianday v1.0.0 // indirect
 	github.com/tetratelabs/wazero v1.8.1 // indirect
-	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/crypto v0.28.0 // indirect
 )
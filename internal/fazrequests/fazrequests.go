@@ -7,7 +7,11 @@ import (
 	"io"
 	"net/http"
 	"regexp"
+	"strings"
 	"time"
+
+	"github.com/slayerjk/faz-get-reports/internal/httpclient"
+	"github.com/slayerjk/faz-get-reports/internal/netconf"
 )
 
 const (
@@ -25,15 +29,58 @@ const (
 
 // define FAZ model struct for JSON response
 type FazModelJson struct {
-	FazUrl          string              `json:"faz-url"`
-	ApiUser         string              `json:"api-user"`
-	ApiUserPass     string              `json:"api-user-pass"`
+	FazUrl      string `json:"faz-url"`
+	ApiUser     string `json:"api-user"`
+	ApiUserPass string `json:"api-user-pass"`
+	// API key(bearer token), session login is used if empty
+	ApiToken        string              `json:"api-token"`
 	FazAdom         string              `json:"faz-adom"`
 	FazDevice       string              `json:"faz-device"`
 	FazDatasetAll   string              `json:"faz-dataset-connections"`
 	FazDatasetTotal string              `json:"faz-dataset-total"`
 	FazReportName   string              `json:"faz-report-name"`
 	FazDatasets     []map[string]string `json:"faz-datasets"`
+	// FAZ log retention(days), older report periods are rejected(not checked if 0)
+	FazLogRetentionDays int `json:"faz-log-retention-days"`
+	// FAZ(ADOM) time zone, ex.: 'Asia/Almaty'(local time zone if empty)
+	FazTimezone string `json:"faz-timezone"`
+	// TLS settings of FAZ connection(system roots by default)
+	FazTLS httpclient.TLS `json:"faz-tls"`
+	// proxy, timeouts & retry policy of FAZ connection
+	FazNetwork netconf.Settings `json:"faz-network"`
+}
+
+// POST JSON-RPC REQUEST, API TOKEN(IF SET) IS SENT AS 'Authorization: Bearer' HEADER
+func (fazData *FazModelJson) post(httpClient *http.Client, fazurl string, body io.Reader) (*http.Response, error) {
+	req, err := fazData.newRequest(fazurl, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return httpClient.Do(req)
+}
+
+// POST IDEMPOTENT JSON-RPC REQUEST('get' methods: status polls, downloads), IT'S RETRIED BY CLIENT ON TEMPORARY FAILURES
+func (fazData *FazModelJson) postIdempotent(httpClient *http.Client, fazurl string, body io.Reader) (*http.Response, error) {
+	req, err := fazData.newRequest(fazurl, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return httpClient.Do(httpclient.Idempotent(req))
+}
+
+func (fazData *FazModelJson) newRequest(fazurl string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, fazurl, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if fazData.ApiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+fazData.ApiToken)
+	}
+
+	return req, nil
 }
 
 // GET SESSION ID TO PERFORM FAZ API REQUESTS
@@ -95,7 +142,7 @@ func (fazData *FazModelJson) GetSessionid(httpClient *http.Client, fazurl, apius
 	// FORMING STRUCTS FOR REQUEST & RESPONSE JSON
 	type Request struct {
 		Method  string `json:"method"`
-		Session string `json:"session"`
+		Session string `json:"session,omitempty"`
 		ID      string `json:"id"`
 		Params  []struct {
 			Data struct {
@@ -114,7 +161,7 @@ func (fazData *FazModelJson) GetSessionid(httpClient *http.Client, fazurl, apius
 			} `json:"status"`
 			URL string `json:"url"`
 		} `json:"result"`
-		Session string `json:"session"`
+		Session string `json:"session,omitempty"`
 		ID      string `json:"id"`
 	}
 
@@ -153,7 +200,7 @@ func (fazData *FazModelJson) GetSessionid(httpClient *http.Client, fazurl, apius
 	requestBody := bytes.NewReader(postBody)
 
 	// MAKING REQUEST
-	resp, err := httpClient.Post(fazurl, contentType, requestBody)
+	resp, err := fazData.post(httpClient, fazurl, requestBody)
 	if err != nil {
 		return "", fmt.Errorf(errRequest, err)
 	}
@@ -281,7 +328,7 @@ func (fazData *FazModelJson) GetFazReportLayout(httpClient *http.Client, fazurl,
 			Apiver int    `json:"apiver"`
 		} `json:"params"`
 		Jsonrpc string `json:"jsonrpc"`
-		Session string `json:"session"`
+		Session string `json:"session,omitempty"`
 		ID      string `json:"id"`
 	}
 
@@ -331,7 +378,7 @@ func (fazData *FazModelJson) GetFazReportLayout(httpClient *http.Client, fazurl,
 	reqBodyBytges := bytes.NewReader(reqBody)
 
 	// MAKING REQUEST & AND CHECKING IT'S CORRECT
-	resp, err := httpClient.Post(fazurl, contentType, reqBodyBytges)
+	resp, err := fazData.postIdempotent(httpClient, fazurl, reqBodyBytges)
 	if err != nil {
 		return 0, fmt.Errorf(errRequest, err)
 	}
@@ -367,6 +414,13 @@ func (fazData *FazModelJson) GetFazReportLayout(httpClient *http.Client, fazurl,
 
 // UPDATING DATASETS FOR REPORTS FOR CORRESPONDING USER
 func (fazData *FazModelJson) UpdateDatasets(httpClient *http.Client, fazurl, sessionid, adom, username string, datasets []map[string]string) error {
+	return fazData.UpdateDatasetsVars(httpClient, fazurl, sessionid, adom, map[string]string{"USERNAME": username}, datasets)
+}
+
+// UPDATING DATASETS FOR REPORTS WITH PLACEHOLDERS('%NAME%') SUBSTITUTION
+//
+// vars keys are placeholder names(case insensitive), unknown placeholders are replaced by vars["USERNAME"]
+func (fazData *FazModelJson) UpdateDatasetsVars(httpClient *http.Client, fazurl, sessionid, adom string, vars map[string]string, datasets []map[string]string) error {
 	/*
 		Correct Request Example(EVERY CONNECT):
 
@@ -447,6 +501,10 @@ func (fazData *FazModelJson) UpdateDatasets(httpClient *http.Client, fazurl, ses
 
 	// REGEXP TO SUBSTITUTE USERNAME IN DATASET QUERY
 	re := regexp.MustCompile(`%\w+%`)
+	upperVars := make(map[string]string, len(vars))
+	for key, value := range vars {
+		upperVars[strings.ToUpper(key)] = value
+	}
 	// REGEXP TO CHECK RESPONSE IS OK
 	reMessageOK := regexp.MustCompile(messageOk)
 
@@ -461,7 +519,7 @@ func (fazData *FazModelJson) UpdateDatasets(httpClient *http.Client, fazurl, ses
 			} `json:"data"`
 		} `json:"params"`
 		Jsonrpc string `json:"jsonrpc"`
-		Session string `json:"session"`
+		Session string `json:"session,omitempty"`
 		ID      string `json:"id"`
 	}
 
@@ -493,7 +551,12 @@ func (fazData *FazModelJson) UpdateDatasets(httpClient *http.Client, fazurl, ses
 	for _, item := range datasets {
 		// FORIMING DATASET QUERY & URL FOR REQUEST
 		datasetUrl = fmt.Sprintf("report/adom/%s/config/dataset/%s", adom, item["dataset"])
-		datasetQuery = re.ReplaceAllLiteralString(item["dataset-query"], username)
+		datasetQuery = re.ReplaceAllStringFunc(item["dataset-query"], func(placeholder string) string {
+			if value, ok := upperVars[strings.ToUpper(strings.Trim(placeholder, "%"))]; ok {
+				return value
+			}
+			return upperVars["USERNAME"]
+		})
 
 		// FORMING JSON FOR DataAll
 		datasetToUpd.Params[0].URL = datasetUrl
@@ -506,7 +569,7 @@ func (fazData *FazModelJson) UpdateDatasets(httpClient *http.Client, fazurl, ses
 		requestBody := bytes.NewReader(json)
 
 		// UPDATING DATASET
-		resp, err := httpClient.Post(fazurl, contentType, requestBody)
+		resp, err := fazData.post(httpClient, fazurl, requestBody)
 		if err != nil {
 			return fmt.Errorf(errRequest, err)
 		}
@@ -629,7 +692,7 @@ func (fazData *FazModelJson) reportIsGenerated(httpClient *http.Client, fazurl,
 			Apiver int    `json:"apiver"`
 			URL    string `json:"url"`
 		} `json:"params"`
-		Session string `json:"session"`
+		Session string `json:"session,omitempty"`
 		ID      string `json:"id"`
 	}
 
@@ -657,7 +720,7 @@ func (fazData *FazModelJson) reportIsGenerated(httpClient *http.Client, fazurl,
 	}
 	reqBodyBytes := bytes.NewReader(reqBody)
 
-	resp, err := httpClient.Post(fazurl, contentType, reqBodyBytes)
+	resp, err := fazData.postIdempotent(httpClient, fazurl, reqBodyBytes)
 	if err != nil {
 		return "", fmt.Errorf("failed to make req of 'reportIsGenerated':\n\t%v\n\treqBody:\n\t%v", err, string(reqBody))
 	}
@@ -743,7 +806,7 @@ func (fazData *FazModelJson) StartReport(httpClient *http.Client, fazurl, adom,
 			} `json:"schedule-param"`
 			URL string `json:"url"`
 		} `json:"params"`
-		Session string `json:"session"`
+		Session string `json:"session,omitempty"`
 		ID      string `json:"id"`
 	}
 
@@ -803,7 +866,7 @@ func (fazData *FazModelJson) StartReport(httpClient *http.Client, fazurl, adom,
 	reqBytes := bytes.NewBuffer(reqBody)
 
 	// MAKING REQUEST
-	resp, err := httpClient.Post(fazurl, contentType, reqBytes)
+	resp, err := fazData.post(httpClient, fazurl, reqBytes)
 	if err != nil {
 		return "", fmt.Errorf(errRequest, err)
 	}
@@ -856,8 +919,16 @@ func (fazData *FazModelJson) StartReport(httpClient *http.Client, fazurl, adom,
 	}
 }
 
+// FORMATS OF GENERATED REPORT TO DOWNLOAD
+var ReportFormats = []string{"PDF", "HTML", "XML", "CSV", "JSON"}
+
 // DOWNLOADING PDF REPORT
 func (fazData *FazModelJson) DownloadPdfReport(httpClient *http.Client, fazUrl, fazAdom, sessionid, repId string) (string, error) {
+	return fazData.DownloadReport(httpClient, fazUrl, fazAdom, sessionid, repId, "PDF")
+}
+
+// DOWNLOADING REPORT IN FORMAT(SEE ReportFormats), DATA IS BASE64 OF ZIP
+func (fazData *FazModelJson) DownloadReport(httpClient *http.Client, fazUrl, fazAdom, sessionid, repId, format string) (string, error) {
 	/*
 		Correct Request Example:
 
@@ -907,7 +978,7 @@ func (fazData *FazModelJson) DownloadPdfReport(httpClient *http.Client, fazUrl,
 			DataType string `json:"data-type"`
 		} `json:"params"`
 		Jsonrpc string `json:"jsonrpc"`
-		Session string `json:"session"`
+		Session string `json:"session,omitempty"`
 		ID      string `json:"id"`
 	}
 
@@ -946,7 +1017,7 @@ func (fazData *FazModelJson) DownloadPdfReport(httpClient *http.Client, fazUrl,
 			{
 				URL:      fmt.Sprintf("report/adom/%s/reports/data/%s", fazAdom, repId),
 				Apiver:   3,
-				Format:   "PDF",
+				Format:   format,
 				DataType: "text",
 			},
 		},
@@ -961,7 +1032,7 @@ func (fazData *FazModelJson) DownloadPdfReport(httpClient *http.Client, fazUrl,
 	respBodyBytes := bytes.NewReader(jsonBody)
 
 	// 	MAKING REQUEST
-	resp, err := httpClient.Post(fazUrl, contentType, respBodyBytes)
+	resp, err := fazData.postIdempotent(httpClient, fazUrl, respBodyBytes)
 	if err != nil {
 		return "", fmt.Errorf(errRequest, err)
 	}
@@ -986,7 +1057,7 @@ func (fazData *FazModelJson) DownloadPdfReport(httpClient *http.Client, fazUrl,
 	// PROCESSING REPORT DATA
 	result = bodyResp.Result.Data
 	if result == "" {
-		return "", fmt.Errorf("result is empty for DownloadPdfReport body.Result.Data")
+		return "", fmt.Errorf("result is empty for DownloadReport(%s) body.Result.Data", format)
 	}
 
 	return result, nil
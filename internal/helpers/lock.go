@@ -0,0 +1,142 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// lock is held by another running instance
+var ErrLocked = errors.New("lock is held by another instance")
+
+// single-instance lock based on OS file lock(flock/LockFileEx)
+//
+// lock file contains PID & start time of holder; it's truncated on Release,
+// so non-empty lock file which is not locked means previous holder didn't release it(stale lock)
+type InstanceLock struct {
+	file *os.File
+	path string
+}
+
+// PID & start time of lock holder
+type LockHolder struct {
+	PID       int
+	StartTime string
+}
+
+func (h LockHolder) String() string {
+	return fmt.Sprintf("PID=%d, START=%s", h.PID, h.StartTime)
+}
+
+// holder is the same process which wrote the lock file
+//
+// process may be checked only by PID if start time is not supported on OS
+func (h LockHolder) IsAlive() bool {
+	if h.PID <= 0 {
+		return false
+	}
+
+	startTime, err := processStartTime(h.PID)
+	if err != nil {
+		return false
+	}
+
+	return h.StartTime == "" || startTime == "" || startTime == h.StartTime
+}
+
+// acquire lock of scopePath(lock file is '<scopePath>.lock'), doesn't wait
+//
+// returns ErrLocked(wrapped, with holder data) if lock is held by another running instance;
+// stale holder is returned if previous holder died without release(lock is acquired anyway)
+func AcquireLock(scopePath string) (*InstanceLock, *LockHolder, error) {
+	lockPath := scopePath + ".lock"
+
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open lock file(%s):\n\t%v", lockPath, err)
+	}
+
+	previous := readLockHolder(file)
+
+	locked, err := lockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to lock file(%s):\n\t%v", lockPath, err)
+	}
+
+	if !locked {
+		file.Close()
+
+		// OS lock is the source of truth, holder data is only for reporting
+		holder := "unknown"
+		if previous != nil {
+			holder = previous.String()
+			if !previous.IsAlive() {
+				holder += ", not running(lock is held by it's child?)"
+			}
+		}
+		return nil, nil, fmt.Errorf("%w(%s): %s", ErrLocked, lockPath, holder)
+	}
+
+	// write own PID & start time
+	startTime, _ := processStartTime(os.Getpid())
+	content := fmt.Sprintf("%d\n%s\n", os.Getpid(), startTime)
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(content), 0)
+		file.Sync()
+	}
+
+	lock := &InstanceLock{file: file, path: lockPath}
+
+	// previous holder didn't release the lock, but it's not running anymore
+	if previous != nil && !previous.IsAlive() {
+		return lock, previous, nil
+	}
+
+	return lock, nil, nil
+}
+
+// lock file path
+func (l *InstanceLock) Path() string {
+	return l.path
+}
+
+// release lock; lock file is kept(truncated) to avoid races of file removal
+func (l *InstanceLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	l.file.Truncate(0)
+	errU := unlockFile(l.file)
+	errC := l.file.Close()
+	l.file = nil
+
+	if errU != nil {
+		return fmt.Errorf("failed to unlock file(%s):\n\t%v", l.path, errU)
+	}
+
+	return errC
+}
+
+// read holder data from lock file, nil if file is empty or malformed
+func readLockHolder(file *os.File) *LockHolder {
+	data := make([]byte, 256)
+
+	n, _ := file.ReadAt(data, 0)
+	lines := strings.Split(strings.TrimSpace(string(data[:n])), "\n")
+
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return nil
+	}
+
+	holder := &LockHolder{PID: pid}
+	if len(lines) > 1 {
+		holder.StartTime = strings.TrimSpace(lines[1])
+	}
+
+	return holder
+}
@@ -0,0 +1,21 @@
+//go:build !unix && !windows
+
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func lockFile(file *os.File) (bool, error) {
+	return false, fmt.Errorf("platform doesn't supported: %s", runtime.GOOS)
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
+
+func processStartTime(pid int) (string, error) {
+	return "", fmt.Errorf("platform doesn't supported: %s", runtime.GOOS)
+}
@@ -0,0 +1,62 @@
+//go:build unix
+
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// try to lock file exclusively, false if it's locked by another process
+func lockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// get process start time(clock ticks since boot from /proc/<pid>/stat)
+//
+// empty start time is returned if process is running, but /proc is not available(ex.: macOS)
+func processStartTime(pid int) (string, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		// no procfs: check process existence only
+		if _, errP := os.Stat("/proc/self/stat"); errP != nil {
+			if errK := syscall.Kill(pid, 0); errK != nil && !errors.Is(errK, syscall.EPERM) {
+				return "", fmt.Errorf("process is not running(%d):\n\t%v", pid, errK)
+			}
+			return "", nil
+		}
+		return "", fmt.Errorf("process is not running(%d):\n\t%v", pid, err)
+	}
+
+	// process name(2nd field) may contain spaces, so fields are counted after it's closing ')'
+	ind := strings.LastIndexByte(string(stat), ')')
+	if ind < 0 {
+		return "", fmt.Errorf("wrong format of /proc/%d/stat", pid)
+	}
+	// starttime is 22nd field, fields after ')' start from 3rd
+	fields := strings.Fields(string(stat[ind+1:]))
+	if len(fields) < 20 {
+		return "", fmt.Errorf("wrong format of /proc/%d/stat", pid)
+	}
+	// zombie process isn't running already
+	if fields[0] == "Z" {
+		return "", fmt.Errorf("process is not running(%d): zombie", pid)
+	}
+
+	return fields[19], nil
+}
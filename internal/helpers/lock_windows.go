@@ -0,0 +1,60 @@
+//go:build windows
+
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// locked byte range is far beyond lock file content, so holder data may be read by other processes
+const lockOffset = 0x7fffffff
+
+// try to lock file exclusively, false if it's locked by another process
+func lockFile(file *os.File) (bool, error) {
+	overlapped := &windows.Overlapped{Offset: lockOffset}
+
+	err := windows.LockFileEx(
+		windows.Handle(file.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, 1, 0, overlapped)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func unlockFile(file *os.File) error {
+	overlapped := &windows.Overlapped{Offset: lockOffset}
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, overlapped)
+}
+
+// get process creation time
+func processStartTime(pid int) (string, error) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return "", fmt.Errorf("process is not running(%d):\n\t%v", pid, err)
+	}
+	defer windows.CloseHandle(handle)
+
+	// exited process may still have handle
+	var exitCode uint32
+	// 259 - STILL_ACTIVE
+	if err := windows.GetExitCodeProcess(handle, &exitCode); err == nil && exitCode != 259 {
+		return "", fmt.Errorf("process is not running(%d): exit code %d", pid, exitCode)
+	}
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return "", fmt.Errorf("failed to get process times(%d):\n\t%v", pid, err)
+	}
+
+	return fmt.Sprintf("%d", creation.Nanoseconds()), nil
+}
@@ -0,0 +1,148 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/slayerjk/faz-get-reports/internal/netconf"
+)
+
+// statuses of temporary failures, idempotent requests are retried on them
+var retryStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+type idempotentKey struct{}
+
+// mark request as idempotent(ex.: FAZ status poll or download, they are POST in JSON-RPC):
+// it's retried on transport errors & temporary failure statuses; GET & HEAD are idempotent without mark
+func Idempotent(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), idempotentKey{}, true))
+}
+
+func isIdempotent(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked
+}
+
+// HTTP client of endpoint(FAZ, Naumen) with its TLS & network settings
+func New(tlsSettings TLS, network netconf.Settings) (*http.Client, error) {
+	tlsConfig, err := tlsSettings.Config()
+	if err != nil {
+		return nil, fmt.Errorf("wrong TLS settings:\n\t%v", err)
+	}
+	if err := network.Validate(); err != nil {
+		return nil, fmt.Errorf("wrong network settings:\n\t%v", err)
+	}
+
+	connectTimeout := network.ConnectTimeout.Or(netconf.DefaultConnectTimeout)
+
+	transport := &http.Transport{
+		Proxy:                 network.HTTPProxy(),
+		DialContext:           (&net.Dialer{Timeout: connectTimeout}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: network.ReadTimeout.Or(netconf.DefaultReadTimeout),
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &http.Client{Transport: &retryTransport{next: transport, retry: network.Retry}}, nil
+}
+
+// temporary failure status of attempt
+type statusError struct {
+	status string
+}
+
+func (e statusError) Error() string {
+	return "temporary failure status: " + e.status
+}
+
+// retry of idempotent requests, other requests are sent once
+type retryTransport struct {
+	next  http.RoundTripper
+	retry netconf.Retry
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	var (
+		response *http.Response
+		attempt  int
+	)
+
+	err := t.retry.Do(req.Context(), retryable, func() error {
+		attempt++
+
+		attemptReq := req
+		if attempt > 1 {
+			// response of previous attempt is replaced
+			if response != nil {
+				io.Copy(io.Discard, response.Body)
+				response.Body.Close()
+				response = nil
+			}
+
+			attemptReq = req.Clone(req.Context())
+			if req.Body != nil && req.Body != http.NoBody {
+				if req.GetBody == nil {
+					return errNoReplay
+				}
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		attemptResp, err := t.next.RoundTrip(attemptReq)
+		if err != nil {
+			return err
+		}
+		response = attemptResp
+
+		if retryStatuses[attemptResp.StatusCode] {
+			return statusError{status: attemptResp.Status}
+		}
+
+		return nil
+	})
+
+	// last temporary failure status is returned to caller as is
+	var statusErr statusError
+	if errors.As(err, &statusErr) && response != nil && req.Context().Err() == nil {
+		return response, nil
+	}
+	if err != nil {
+		if response != nil {
+			response.Body.Close()
+		}
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// body of request can't be sent again
+var errNoReplay = errors.New("request body can't be replayed")
+
+// transport errors & temporary failure statuses are retried, cancellation & not replayable body are not
+func retryable(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, errNoReplay)
+}
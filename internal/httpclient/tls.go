@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// certificate of server is not pinned
+var ErrNotPinned = errors.New("certificate is not pinned")
+
+// TLS settings of endpoint(ex.: 'faz-tls' of faz-data.json)
+//
+// server certificate is verified by system roots(plus CA bundle) by default
+type TLS struct {
+	// PEM bundle of extra CAs(ex.: internal PKI), added to system roots
+	CAFile string `json:"ca-file"`
+	// SHA-256 fingerprints of allowed certificates(hex, colons are allowed: 'AB:CD:...'),
+	// pinned certificate is trusted even if it's self-signed, certificate which isn't pinned is rejected
+	PinSHA256 []string `json:"pin-sha256"`
+	// client certificate & its key(PEM)
+	CertFile string `json:"cert-file"`
+	KeyFile  string `json:"key-file"`
+	// don't verify server certificate at all(explicit opt-in, it's logged as warning on every config load)
+	Insecure bool `json:"insecure"`
+}
+
+// tls.Config of settings
+func (t TLS) Config() (*tls.Config, error) {
+	if t.Insecure {
+		if t.CAFile != "" || len(t.PinSHA256) != 0 {
+			return nil, fmt.Errorf("insecure can't be combined with ca-file or pin-sha256")
+		}
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if t.CAFile != "" {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		bundle, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle(%s):\n\t%v", t.CAFile, err)
+		}
+		if !roots.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle(%s)", t.CAFile)
+		}
+		config.RootCAs = roots
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate(%s, %s):\n\t%v", t.CertFile, t.KeyFile, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(t.PinSHA256) != 0 {
+		pins := make(map[string]bool, len(t.PinSHA256))
+		for _, pin := range t.PinSHA256 {
+			normalized := normalizePin(pin)
+			if _, err := hex.DecodeString(normalized); err != nil || len(normalized) != sha256.Size*2 {
+				return nil, fmt.Errorf("wrong pin-sha256(%s): must be hex of 32 bytes", pin)
+			}
+			pins[normalized] = true
+		}
+
+		// chain is verified by verifyPinned: pinned self-signed certificate must pass
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPinned(state, config.RootCAs, pins)
+		}
+	}
+
+	return config, nil
+}
+
+// certificate chain is valid & contains pinned certificate, or server certificate itself is pinned
+func verifyPinned(state tls.ConnectionState, roots *x509.CertPool, pins map[string]bool) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("server sent no certificates")
+	}
+	leaf := state.PeerCertificates[0]
+
+	if pins[fingerprint(leaf)] {
+		return nil
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       state.ServerName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, chain := range chains {
+		for _, cert := range chain {
+			if pins[fingerprint(cert)] {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %s(SHA-256 %s)", ErrNotPinned, leaf.Subject, fingerprint(leaf))
+}
+
+// SHA-256 fingerprint of certificate, lower hex
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// lower hex without colons & spaces
+func normalizePin(pin string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(pin)))
+}
@@ -0,0 +1,259 @@
+package ldapresolver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// AD userAccountControl flag of disabled account
+const uacAccountDisable = 0x2
+
+// attributes of user to read
+var userAttributes = []string{"sAMAccountName", "displayName", "userPrincipalName", "userAccountControl", "sn", "givenName", "middleName"}
+
+var (
+	// account is not found in LDAP
+	ErrNotFound = errors.New("account not found")
+	// account is disabled in AD
+	ErrDisabled = errors.New("account is disabled")
+	// several accounts match the name
+	ErrAmbiguous = errors.New("several accounts found")
+)
+
+// ldap-data.json
+type Config struct {
+	BindUser string `json:"ldap-bind-user"`
+	BindPass string `json:"ldap-bind-pass"`
+	Fqdn     string `json:"ldap-fqdn"`
+	BaseDn   string `json:"ldap-basedn"`
+	// LDAP server URL(optional), 'ldaps://<ldap-fqdn>:636' by default; 'ldap://' URL is upgraded by StartTLS unless ldap-plain is set
+	Url string `json:"ldap-url"`
+	// use 'ldap://' URL without StartTLS(ex.: local LDAP stand-in)
+	Plain bool `json:"ldap-plain"`
+	// search filter of users(optional), '(objectClass=user)' by default
+	UserFilter string `json:"ldap-user-filter"`
+}
+
+// resolved AD user
+type Identity struct {
+	SAMAccountName string
+	DisplayName    string
+	UPN            string
+	DN             string
+	// 'Surname N.P.'
+	ShortName string
+}
+
+// AD matching rule of nested group membership('LDAP_MATCHING_RULE_IN_CHAIN')
+const matchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// page size of group members search
+const membersPageSize = 500
+
+// LDAP operations of resolver(*ldap.Conn)
+type directory interface {
+	Search(request *ldap.SearchRequest) (*ldap.SearchResult, error)
+	SearchWithPaging(request *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// LDAP resolver of users, one bound connection
+type Resolver struct {
+	conn   directory
+	config Config
+}
+
+// connect & bind to LDAP server
+func Dial(config Config) (*Resolver, error) {
+	if config.BaseDn == "" {
+		return nil, fmt.Errorf("ldap-basedn is empty")
+	}
+
+	url := config.Url
+	if url == "" {
+		if config.Fqdn == "" {
+			return nil, fmt.Errorf("both ldap-url & ldap-fqdn are empty")
+		}
+		url = "ldaps://" + config.Fqdn + ":636"
+	}
+
+	conn, err := ldap.DialURL(url, ldap.DialWithTLSConfig(&tls.Config{ServerName: config.Fqdn}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP(%s):\n\t%v", url, err)
+	}
+
+	if strings.HasPrefix(url, "ldap://") && !config.Plain {
+		if err := conn.StartTLS(&tls.Config{ServerName: config.Fqdn}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to StartTLS(%s):\n\t%v", url, err)
+		}
+	}
+
+	if err := conn.Bind(config.BindUser, config.BindPass); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to bind to LDAP(%s) as %s:\n\t%v", url, config.BindUser, err)
+	}
+
+	return &Resolver{conn: conn, config: config}, nil
+}
+
+// close LDAP connection
+func (r *Resolver) Close() error {
+	return r.conn.Close()
+}
+
+// find enabled user by sAMAccountName, UPN or displayName
+func (r *Resolver) Resolve(account string) (*Identity, error) {
+	account = strings.TrimSpace(account)
+	if account == "" {
+		return nil, fmt.Errorf("%w: empty account name", ErrNotFound)
+	}
+
+	userFilter := r.config.UserFilter
+	if userFilter == "" {
+		userFilter = "(objectClass=user)"
+	}
+	escaped := ldap.EscapeFilter(account)
+	filter := fmt.Sprintf("(&%s(|(sAMAccountName=%s)(userPrincipalName=%s)(displayName=%s)))", userFilter, escaped, escaped, escaped)
+
+	request := ldap.NewSearchRequest(
+		r.config.BaseDn,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		filter, userAttributes, nil)
+
+	result, err := r.conn.Search(request)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("failed to search account(%s):\n\t%v", account, err)
+	}
+
+	switch {
+	case result == nil || len(result.Entries) == 0:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, account)
+	case len(result.Entries) > 1:
+		return nil, fmt.Errorf("%w: %s", ErrAmbiguous, account)
+	}
+
+	return identityOf(result.Entries[0], account)
+}
+
+// identity of user entry, ErrDisabled if account is disabled
+func identityOf(entry *ldap.Entry, account string) (*Identity, error) {
+	uac, _ := strconv.Atoi(entry.GetAttributeValue("userAccountControl"))
+	if uac&uacAccountDisable != 0 {
+		return nil, fmt.Errorf("%w: %s(%s)", ErrDisabled, account, entry.DN)
+	}
+
+	identity := &Identity{
+		SAMAccountName: entry.GetAttributeValue("sAMAccountName"),
+		DisplayName:    entry.GetAttributeValue("displayName"),
+		UPN:            entry.GetAttributeValue("userPrincipalName"),
+		DN:             entry.DN,
+	}
+	identity.ShortName = shortName(
+		entry.GetAttributeValue("sn"),
+		entry.GetAttributeValue("givenName"),
+		entry.GetAttributeValue("middleName"),
+		identity.DisplayName)
+
+	if identity.SAMAccountName == "" {
+		identity.SAMAccountName = account
+	}
+	if identity.ShortName == "" {
+		identity.ShortName = identity.SAMAccountName
+	}
+
+	return identity, nil
+}
+
+// members of group(by cn, sAMAccountName or DN), members of nested groups are included
+//
+// members are found by one paged search of users in chain of group(AD resolves nesting & cycles,
+// groups of any size are read, unlike ranged 'member' attribute); disabled members are skipped and returned separately(DN)
+func (r *Resolver) GroupMembers(group string) ([]Identity, []string, error) {
+	group = strings.TrimSpace(group)
+	if group == "" {
+		return nil, nil, fmt.Errorf("%w: empty group name", ErrNotFound)
+	}
+
+	escaped := ldap.EscapeFilter(group)
+	filter := fmt.Sprintf("(&(objectClass=group)(|(cn=%s)(sAMAccountName=%s)(distinguishedName=%s)))", escaped, escaped, escaped)
+
+	request := ldap.NewSearchRequest(
+		r.config.BaseDn,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		filter, []string{"cn"}, nil)
+
+	result, err := r.conn.Search(request)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, nil, fmt.Errorf("failed to search group(%s):\n\t%v", group, err)
+	}
+
+	switch {
+	case result == nil || len(result.Entries) == 0:
+		return nil, nil, fmt.Errorf("%w: group %s", ErrNotFound, group)
+	case len(result.Entries) > 1:
+		return nil, nil, fmt.Errorf("%w: group %s", ErrAmbiguous, group)
+	}
+
+	// computer accounts are users too in AD
+	membersFilter := fmt.Sprintf("(&(objectClass=user)(!(objectClass=computer))(memberOf:%s:=%s))",
+		matchingRuleInChain, ldap.EscapeFilter(result.Entries[0].DN))
+
+	membersRequest := ldap.NewSearchRequest(
+		r.config.BaseDn,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		membersFilter, userAttributes, nil)
+
+	membersResult, err := r.conn.SearchWithPaging(membersRequest, membersPageSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search members of group(%s):\n\t%v", group, err)
+	}
+
+	var (
+		members  []Identity
+		disabled []string
+	)
+	for _, entry := range membersResult.Entries {
+		identity, err := identityOf(entry, "")
+		if err != nil {
+			disabled = append(disabled, entry.DN)
+			continue
+		}
+		members = append(members, *identity)
+	}
+
+	return members, disabled, nil
+}
+
+// 'Surname N.P.' of surname, name & patronymic(may be blank) or of displayName('Surname Name Patronymic')
+func shortName(surname, name, patronymic, displayName string) string {
+	if surname == "" || name == "" {
+		parts := strings.Fields(displayName)
+		if len(parts) < 2 {
+			return displayName
+		}
+		surname, name = parts[0], parts[1]
+		if len(parts) > 2 {
+			patronymic = parts[2]
+		}
+	}
+
+	result := surname + " " + initial(name)
+	if patronymic != "" {
+		result += initial(patronymic)
+	}
+
+	return result
+}
+
+// first letter of name with dot
+func initial(name string) string {
+	first, _ := utf8.DecodeRuneInString(name)
+	return strings.ToUpper(string(first)) + "."
+}
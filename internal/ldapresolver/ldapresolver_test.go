@@ -0,0 +1,429 @@
+package ldapresolver
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const testBaseDn = "DC=corp,DC=local"
+
+// in-memory stand-in of AD: search by base DN & scope with filter evaluation
+//
+// memberOf of entries is computed from 'member' of groups, as AD does
+type fakeDirectory struct {
+	entries []*ldap.Entry
+	// number of Search & SearchWithPaging calls
+	searches int
+	// page sizes of SearchWithPaging calls
+	pageSizes []uint32
+}
+
+func (d *fakeDirectory) Close() error {
+	return nil
+}
+
+func (d *fakeDirectory) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.searches++
+
+	filter, err := ldap.CompileFilter(request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ldap.SearchResult{}
+
+	if request.Scope == ldap.ScopeBaseObject {
+		entry := d.byDN(request.BaseDN)
+		if entry == nil {
+			return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, fmt.Errorf("no such object: %s", request.BaseDN))
+		}
+		if d.match(entry, filter) {
+			result.Entries = append(result.Entries, entry)
+		}
+		return result, nil
+	}
+
+	for _, entry := range d.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), strings.ToLower(request.BaseDN)) || !d.match(entry, filter) {
+			continue
+		}
+		if request.SizeLimit > 0 && len(result.Entries) == request.SizeLimit {
+			return result, ldap.NewError(ldap.LDAPResultSizeLimitExceeded, errors.New("size limit exceeded"))
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+
+	return result, nil
+}
+
+// all results at once(paging is done by *ldap.Conn)
+func (d *fakeDirectory) SearchWithPaging(request *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error) {
+	d.pageSizes = append(d.pageSizes, pagingSize)
+	return d.Search(request)
+}
+
+func (d *fakeDirectory) byDN(dn string) *ldap.Entry {
+	for _, entry := range d.entries {
+		if strings.EqualFold(entry.DN, dn) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// values of attribute, 'memberOf' & 'distinguishedName' are computed
+func (d *fakeDirectory) values(entry *ldap.Entry, attribute string) []string {
+	switch strings.ToLower(attribute) {
+	case "distinguishedname":
+		return []string{entry.DN}
+	case "memberof":
+		var groups []string
+		for _, group := range d.entries {
+			if hasValue(group.GetAttributeValues("member"), entry.DN) {
+				groups = append(groups, group.DN)
+			}
+		}
+		return groups
+	}
+	return entry.GetAttributeValues(attribute)
+}
+
+// entry is member of group directly or through nested groups
+func (d *fakeDirectory) inChain(entry *ldap.Entry, groupDN string, visited map[string]bool) bool {
+	for _, dn := range d.values(entry, "memberOf") {
+		if strings.EqualFold(dn, groupDN) {
+			return true
+		}
+		if visited[strings.ToLower(dn)] {
+			continue
+		}
+		visited[strings.ToLower(dn)] = true
+		if group := d.byDN(dn); group != nil && d.inChain(group, groupDN, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate compiled filter: and, or, not, equality, present & extensible match 'LDAP_MATCHING_RULE_IN_CHAIN'
+func (d *fakeDirectory) match(entry *ldap.Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !d.match(entry, child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if d.match(entry, child) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !d.match(entry, filter.Children[0])
+	case ldap.FilterEqualityMatch:
+		return hasValue(d.values(entry, filter.Children[0].Value.(string)), filter.Children[1].Value.(string))
+	case ldap.FilterPresent:
+		return strings.EqualFold(filter.Value.(string), "objectClass") || len(d.values(entry, filter.Value.(string))) != 0
+	case ldap.FilterExtensibleMatch:
+		assertion := make(map[ber.Tag]string)
+		for _, child := range filter.Children {
+			if value, ok := child.Value.(string); ok {
+				assertion[child.Tag] = value
+			}
+		}
+		if assertion[ldap.MatchingRuleAssertionMatchingRule] != "1.2.840.113556.1.4.1941" || !strings.EqualFold(assertion[ldap.MatchingRuleAssertionType], "memberOf") {
+			return false
+		}
+		return d.inChain(entry, assertion[ldap.MatchingRuleAssertionMatchValue], make(map[string]bool))
+	}
+	return false
+}
+
+func hasValue(values []string, value string) bool {
+	for _, item := range values {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func user(cn, sam, uac string, attributes map[string][]string) *ldap.Entry {
+	values := map[string][]string{
+		"objectClass":        {"top", "person", "organizationalPerson", "user"},
+		"cn":                 {cn},
+		"sAMAccountName":     {sam},
+		"userPrincipalName":  {strings.ToLower(sam) + "@corp.local"},
+		"userAccountControl": {uac},
+	}
+	for name, value := range attributes {
+		values[name] = value
+	}
+	return ldap.NewEntry("CN="+cn+",OU=Users,"+testBaseDn, values)
+}
+
+func group(cn string, members ...string) *ldap.Entry {
+	return ldap.NewEntry("CN="+cn+",OU=Groups,"+testBaseDn, map[string][]string{
+		"objectClass":    {"top", "group"},
+		"cn":             {cn},
+		"sAMAccountName": {cn},
+		"member":         members,
+	})
+}
+
+func testResolver() (*Resolver, *fakeDirectory) {
+	ivanov := user("Иванов Иван Иванович", "IVANOVII", "512", map[string][]string{
+		"displayName": {"Иванов Иван Иванович"},
+		"sn":          {"Иванов"},
+		"givenName":   {"Иван"},
+		"middleName":  {"Иванович"},
+	})
+	petrov := user("Петров Петр", "PETROVP", "514", map[string][]string{"displayName": {"Петров Петр"}})
+	smith := user("John Smith", "SMITHJ", "66048", map[string][]string{"displayName": {"Smith John"}})
+	twin1 := user("Twin One", "TWIN1", "512", map[string][]string{"displayName": {"Twin"}})
+	twin2 := user("Twin Two", "TWIN2", "512", map[string][]string{"displayName": {"Twin"}})
+	computer := ldap.NewEntry("CN=WS01,OU=Computers,"+testBaseDn, map[string][]string{
+		"objectClass":        {"top", "person", "organizationalPerson", "user", "computer"},
+		"cn":                 {"WS01"},
+		"sAMAccountName":     {"WS01$"},
+		"userAccountControl": {"4096"},
+	})
+
+	nestedDN := "CN=Nested,OU=Groups," + testBaseDn
+	vpnDN := "CN=VPN-Contractors,OU=Groups," + testBaseDn
+
+	directory := &fakeDirectory{entries: []*ldap.Entry{
+		ivanov, petrov, smith, twin1, twin2, computer,
+		// nested group is member of its parent(cycle), ivanov is member of both
+		group("VPN-Contractors", ivanov.DN, petrov.DN, nestedDN, computer.DN, "CN=Deleted,OU=Users,"+testBaseDn),
+		group("Nested", smith.DN, ivanov.DN, vpnDN),
+		group("Disabled-Only", petrov.DN),
+		group("Empty"),
+		group("Twins"),
+		ldap.NewEntry("CN=Twins,OU=Other,"+testBaseDn, map[string][]string{"objectClass": {"group"}, "cn": {"Twins"}}),
+	}}
+
+	return &Resolver{conn: directory, config: Config{BaseDn: testBaseDn}}, directory
+}
+
+func TestResolve(t *testing.T) {
+	resolver, _ := testResolver()
+
+	tests := []struct {
+		name    string
+		account string
+		want    *Identity
+		wantErr error
+	}{
+		{
+			name:    "sAMAccountName",
+			account: "IVANOVII",
+			want: &Identity{
+				SAMAccountName: "IVANOVII",
+				DisplayName:    "Иванов Иван Иванович",
+				UPN:            "ivanovii@corp.local",
+				DN:             "CN=Иванов Иван Иванович,OU=Users," + testBaseDn,
+				ShortName:      "Иванов И.И.",
+			},
+		},
+		{
+			name:    "UPN",
+			account: " smithj@corp.local ",
+			want: &Identity{
+				SAMAccountName: "SMITHJ",
+				DisplayName:    "Smith John",
+				UPN:            "smithj@corp.local",
+				DN:             "CN=John Smith,OU=Users," + testBaseDn,
+				ShortName:      "Smith J.",
+			},
+		},
+		{name: "displayName", account: "Петров Петр", wantErr: ErrDisabled},
+		{name: "not found", account: "NOBODY", wantErr: ErrNotFound},
+		{name: "empty", account: " ", wantErr: ErrNotFound},
+		{name: "filter chars are escaped", account: "*", wantErr: ErrNotFound},
+		{name: "ambiguous", account: "Twin", wantErr: ErrAmbiguous},
+		{name: "disabled", account: "PETROVP", wantErr: ErrDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(tt.account)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve(%q) error = %v, want %v", tt.account, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) error: %v", tt.account, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve(%q) = %+v, want %+v", tt.account, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupMembers(t *testing.T) {
+	resolver, _ := testResolver()
+
+	tests := []struct {
+		name         string
+		group        string
+		wantMembers  []string
+		wantDisabled []string
+		wantErr      error
+	}{
+		{
+			name:         "nested cyclic group",
+			group:        "VPN-Contractors",
+			wantMembers:  []string{"IVANOVII", "SMITHJ"},
+			wantDisabled: []string{"CN=Петров Петр,OU=Users," + testBaseDn},
+		},
+		{
+			name:         "group by DN",
+			group:        "CN=Nested,OU=Groups," + testBaseDn,
+			wantMembers:  []string{"IVANOVII", "SMITHJ"},
+			wantDisabled: []string{"CN=Петров Петр,OU=Users," + testBaseDn},
+		},
+		{
+			name:         "only disabled members",
+			group:        "Disabled-Only",
+			wantDisabled: []string{"CN=Петров Петр,OU=Users," + testBaseDn},
+		},
+		{name: "empty group", group: "Empty"},
+		{name: "not found", group: "NO-SUCH-GROUP", wantErr: ErrNotFound},
+		{name: "user is not a group", group: "IVANOVII", wantErr: ErrNotFound},
+		{name: "empty name", group: "", wantErr: ErrNotFound},
+		{name: "ambiguous", group: "Twins", wantErr: ErrAmbiguous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members, disabled, err := resolver.GroupMembers(tt.group)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GroupMembers(%q) error = %v, want %v", tt.group, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GroupMembers(%q) error: %v", tt.group, err)
+			}
+
+			var names []string
+			for _, member := range members {
+				names = append(names, member.SAMAccountName)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantMembers) {
+				t.Errorf("GroupMembers(%q) members = %v, want %v", tt.group, names, tt.wantMembers)
+			}
+			if !reflect.DeepEqual(disabled, tt.wantDisabled) {
+				t.Errorf("GroupMembers(%q) disabled = %v, want %v", tt.group, disabled, tt.wantDisabled)
+			}
+		})
+	}
+}
+
+// group larger than AD range of 'member'(1500 values) is read by one paged search, not per member
+func TestGroupMembersLarge(t *testing.T) {
+	resolver, directory := testResolver()
+
+	var dns []string
+	for i := 0; i < 2000; i++ {
+		entry := user(fmt.Sprintf("User %04d", i), fmt.Sprintf("USER%04d", i), "512", nil)
+		directory.entries = append(directory.entries, entry)
+		dns = append(dns, entry.DN)
+	}
+	directory.entries = append(directory.entries, group("Big", dns...))
+	directory.searches = 0
+
+	members, disabled, err := resolver.GroupMembers("Big")
+	if err != nil {
+		t.Fatalf("GroupMembers() error: %v", err)
+	}
+	if len(members) != 2000 || len(disabled) != 0 {
+		t.Errorf("GroupMembers() = %d members, %d disabled, want 2000 & 0", len(members), len(disabled))
+	}
+	if directory.searches != 2 {
+		t.Errorf("GroupMembers() made %d searches, want 2(group & its members)", directory.searches)
+	}
+	if len(directory.pageSizes) != 1 || directory.pageSizes[0] == 0 {
+		t.Errorf("GroupMembers() page sizes = %v, want one paged search", directory.pageSizes)
+	}
+}
+
+func TestIdentityOf(t *testing.T) {
+	tests := []struct {
+		name          string
+		attributes    map[string][]string
+		wantSAM       string
+		wantShortName string
+		wantErr       error
+	}{
+		{name: "enabled", attributes: map[string][]string{"sAMAccountName": {"USER1"}, "userAccountControl": {"512"}}, wantSAM: "USER1", wantShortName: "USER1"},
+		{name: "enabled, password never expires", attributes: map[string][]string{"sAMAccountName": {"USER1"}, "userAccountControl": {"66048"}}, wantSAM: "USER1", wantShortName: "USER1"},
+		{name: "disabled", attributes: map[string][]string{"sAMAccountName": {"USER1"}, "userAccountControl": {"514"}}, wantErr: ErrDisabled},
+		{name: "disabled with other flags", attributes: map[string][]string{"sAMAccountName": {"USER1"}, "userAccountControl": {"66050"}}, wantErr: ErrDisabled},
+		{name: "no userAccountControl", attributes: map[string][]string{"sAMAccountName": {"USER1"}}, wantSAM: "USER1", wantShortName: "USER1"},
+		{name: "no sAMAccountName", attributes: map[string][]string{"displayName": {"Сидоров Сидор"}}, wantSAM: "account", wantShortName: "Сидоров С."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := identityOf(ldap.NewEntry("CN=User1,"+testBaseDn, tt.attributes), "account")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("identityOf() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("identityOf() error: %v", err)
+			}
+			if got.SAMAccountName != tt.wantSAM || got.ShortName != tt.wantShortName {
+				t.Errorf("identityOf() = %+v, want %s(%s)", got, tt.wantSAM, tt.wantShortName)
+			}
+		})
+	}
+}
+
+func TestShortName(t *testing.T) {
+	tests := []struct {
+		name        string
+		surname     string
+		givenName   string
+		patronymic  string
+		displayName string
+		want        string
+	}{
+		{name: "full Cyrillic name", surname: "Иванов", givenName: "Иван", patronymic: "Иванович", want: "Иванов И.И."},
+		{name: "lower case initials", surname: "Ёлкина", givenName: "юлия", patronymic: "андреевна", want: "Ёлкина Ю.А."},
+		{name: "no patronymic", surname: "Петров", givenName: "Петр", want: "Петров П."},
+		{name: "Latin name", surname: "Smith", givenName: "John", want: "Smith J."},
+		{name: "displayName fallback", displayName: "Сидоров Сидор Сидорович", want: "Сидоров С.С."},
+		{name: "displayName without patronymic", surname: "Сидоров", displayName: "Сидоров Сидор", want: "Сидоров С."},
+		{name: "one word displayName", displayName: "Администратор", want: "Администратор"},
+		{name: "nothing", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shortName(tt.surname, tt.givenName, tt.patronymic, tt.displayName); got != tt.want {
+				t.Errorf("shortName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
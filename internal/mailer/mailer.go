@@ -0,0 +1,218 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/slayerjk/faz-get-reports/internal/netconf"
+)
+
+// mailing.json
+type MailData struct {
+	Host          string   `json:"host"`
+	Port          string   `json:"port"`
+	AuthUser      string   `json:"auth_user"`
+	AuthPass      string   `json:"auth_pass"`
+	FromAddr      string   `json:"from_addr"`
+	ToAddrErrors  []string `json:"to_addr_errors"`
+	ToAddrReports []string `json:"to_addr_reports"`
+	// proxy, timeouts & retry policy of SMTP connection(only connecting is retried: mail must not be sent twice)
+	Network netconf.Settings `json:"smtp-network"`
+}
+
+// read mailing data file
+func ReadMailData(dataFile string) (MailData, error) {
+	var result MailData
+
+	data, err := os.ReadFile(dataFile)
+	if err != nil {
+		return result, fmt.Errorf("failed to read mailing data file:\n\t%v", err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("failed to unmarshall mailing data:\n\t%v", err)
+	}
+
+	return result, nil
+}
+
+// send plain text mail without auth(typically smtp:25) to recipients of msgType('error' or 'report')
+//
+// subject: '<appName> - <msgType>(DD.MM.YYYY hh:mm)'
+func SendPlainEmailWoAuth(dataFile, msgType, appName string, msg []byte) error {
+	mailData, err := ReadMailData(dataFile)
+	if err != nil {
+		return fmt.Errorf("failed to get mailing data file:\n\t%v", err)
+	}
+
+	return Send(mailData, msgType, appName, msg)
+}
+
+// send plain text mail without auth of mailing data(ex.: 'mailing' section of config file), see SendPlainEmailWoAuth
+func Send(mailData MailData, msgType, appName string, msg []byte) error {
+	if err := mailData.Network.Validate(); err != nil {
+		return fmt.Errorf("wrong smtp-network settings:\n\t%v", err)
+	}
+
+	var toAddr []string
+	switch msgType {
+	case "error":
+		toAddr = mailData.ToAddrErrors
+	case "report":
+		toAddr = mailData.ToAddrReports
+	default:
+		return fmt.Errorf("wrong msgType: neither 'error' nor 'report'")
+	}
+	if len(toAddr) == 0 {
+		return fmt.Errorf("no recipients of %s", msgType)
+	}
+
+	subject := fmt.Sprintf("%s - %s(%s)", appName, msgType, time.Now().Format("02.01.2006 15:04"))
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		mailData.FromAddr, strings.Join(toAddr, ","), subject, msg)
+
+	if err := send(mailData, toAddr, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send mail(%s:%s):\n\t%v", mailData.Host, mailData.Port, err)
+	}
+
+	return nil
+}
+
+// send report files as attachments to recipients(ex.: email column of users.csv), msg is text of mail
+//
+// subject: '<appName> - report(DD.MM.YYYY hh:mm)'
+func SendFiles(mailData MailData, toAddr []string, appName string, msg []byte, files []string) error {
+	if err := mailData.Network.Validate(); err != nil {
+		return fmt.Errorf("wrong smtp-network settings:\n\t%v", err)
+	}
+	if len(toAddr) == 0 {
+		return fmt.Errorf("no recipients of files")
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}})
+	if err != nil {
+		return err
+	}
+	part.Write(msg)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read attachment:\n\t%v", err)
+		}
+
+		contentType := mime.TypeByExtension(filepath.Ext(file))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		name := mime.QEncoding.Encode("UTF-8", filepath.Base(file))
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=\"%s\"", contentType, name)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=\"%s\"", name)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return err
+		}
+
+		// base64 lines of 76 characters(RFC 2045)
+		encoded := base64.StdEncoding.EncodeToString(data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("%s - report(%s)", appName, time.Now().Format("02.01.2006 15:04"))
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n",
+		mailData.FromAddr, strings.Join(toAddr, ","), subject, writer.Boundary())
+
+	if err := send(mailData, toAddr, append([]byte(message), body.Bytes()...)); err != nil {
+		return fmt.Errorf("failed to send mail(%s:%s):\n\t%v", mailData.Host, mailData.Port, err)
+	}
+
+	return nil
+}
+
+// SMTP session: connecting is retried, every command has read timeout
+func send(mailData MailData, toAddr []string, message []byte) error {
+	network := mailData.Network
+	addr := net.JoinHostPort(mailData.Host, mailData.Port)
+	readTimeout := network.ReadTimeout.Or(netconf.DefaultReadTimeout)
+
+	var conn net.Conn
+	err := network.Retry.Do(context.Background(), func(error) bool { return true }, func() error {
+		var err error
+		conn, err = network.DialContext(context.Background(), "tcp", addr)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// deadline of next command
+	next := func() {
+		conn.SetDeadline(time.Now().Add(readTimeout))
+	}
+
+	next()
+	client, err := smtp.NewClient(conn, mailData.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	next()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: mailData.Host}); err != nil {
+			return err
+		}
+	}
+
+	next()
+	if err := client.Mail(mailData.FromAddr); err != nil {
+		return err
+	}
+	for _, to := range toAddr {
+		next()
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	next()
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	next()
+	return client.Quit()
+}
@@ -4,24 +4,80 @@ import (
 	// sqllite support
 	"database/sql"
 	"fmt"
+	"regexp"
 	"time"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
 )
 
+// date format of db date columns
+const dateLayout = "02.01.2006 15:04:05"
+
+// allowed table/column identifier
+var reIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// names of Naumen data table & it's columns
+type DataTable struct {
+	Table               string
+	ValueColumn         string
+	PostedDateColumn    string
+	ProcessedColumn     string
+	ProcessedDateColumn string
+	ServiceCallColumn   string
+}
+
 // define db model struct
 type DbModel struct {
 	DB *sql.DB
+	// queries of Naumen data table, formed once in NewDbModel
+	selectUnprocessedQuery string
+	updProcessedQuery      string
+	insertValueQuery       string
+	resetValueQuery        string
+	updServiceCallQuery    string
+	selectServiceCallQuery string
+}
+
+// make db model with validated Naumen data table identifiers
+func NewDbModel(db *sql.DB, data DataTable) (*DbModel, error) {
+	for _, ident := range []string{data.Table, data.ValueColumn, data.PostedDateColumn, data.ProcessedColumn, data.ProcessedDateColumn, data.ServiceCallColumn} {
+		if !reIdentifier.MatchString(ident) {
+			return nil, fmt.Errorf("wrong db identifier(must match %s): '%s'", reIdentifier, ident)
+		}
+	}
+
+	model := &DbModel{
+		DB: db,
+		selectUnprocessedQuery: fmt.Sprintf(
+			`SELECT "%s" FROM "%s" WHERE "%s" IS NULL`,
+			data.ValueColumn, data.Table, data.ProcessedColumn),
+		updProcessedQuery: fmt.Sprintf(
+			`UPDATE "%s" SET "%s" = ?, "%s" = ? WHERE "%s" = ?`,
+			data.Table, data.ProcessedColumn, data.ProcessedDateColumn, data.ValueColumn),
+		insertValueQuery: fmt.Sprintf(
+			`INSERT INTO "%s" ("%s", "%s") VALUES (?, ?) ON CONFLICT ("%s") DO NOTHING`,
+			data.Table, data.ValueColumn, data.PostedDateColumn, data.ValueColumn),
+		resetValueQuery: fmt.Sprintf(
+			`UPDATE "%s" SET "%s" = NULL, "%s" = NULL WHERE "%s" = ?`,
+			data.Table, data.ProcessedColumn, data.ProcessedDateColumn, data.ValueColumn),
+		updServiceCallQuery: fmt.Sprintf(
+			`UPDATE "%s" SET "%s" = ? WHERE "%s" = ?`,
+			data.Table, data.ServiceCallColumn, data.ValueColumn),
+		selectServiceCallQuery: fmt.Sprintf(
+			`SELECT "%s", "%s" FROM "%s" WHERE "%s" IS NOT NULL`,
+			data.ValueColumn, data.ServiceCallColumn, data.Table, data.ServiceCallColumn),
+	}
+
+	return model, nil
 }
 
 // get list of unprocessed values in db('Processed' column = NULL)
-func (model *DbModel) GetUnprocessedDbValues(dbFile, dbTable, dbValueColumn, dbProcessedColumn string) ([]string, error) {
+func (model *DbModel) GetUnprocessedDbValues() ([]string, error) {
 	result := make([]string, 0)
 
 	// get select result of unprocessed values('Processed' column = NULL)
-	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NULL", dbValueColumn, dbTable, dbProcessedColumn)
-	rows, err := model.DB.Query(query)
+	rows, err := model.DB.Query(model.selectUnprocessedQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make select of unprocessed values:\n\t%v", err)
 	}
@@ -36,18 +92,15 @@ func (model *DbModel) GetUnprocessedDbValues(dbFile, dbTable, dbValueColumn, dbP
 		result = append(result, value)
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
 // update processed value(0 - for failed, 1 - for succeeded)
-func (model *DbModel) UpdDbValue(dbFile, dbTable, dbValueColumn, dbColumnToUpd, dbProcessedDateColumn, valueToUpd string, updTo int) error {
+func (model *DbModel) UpdDbValue(valueToUpd string, updTo int) error {
 	// upd db value
-	processedDate := time.Now().Format("02.01.2006 15:04:05")
-	query := fmt.Sprintf(
-		"UPDATE %s SET %s = %d, %s = '%s' WHERE %s = '%s'",
-		dbTable, dbColumnToUpd, updTo, dbProcessedDateColumn, processedDate, dbValueColumn, valueToUpd)
+	processedDate := time.Now().Format(dateLayout)
 
-	result, errU := model.DB.Exec(query)
+	result, errU := model.DB.Exec(model.updProcessedQuery, updTo, processedDate, valueToUpd)
 	if errU != nil {
 		return errU
 	}
@@ -55,8 +108,95 @@ func (model *DbModel) UpdDbValue(dbFile, dbTable, dbValueColumn, dbColumnToUpd,
 	// if 0 affected rows than something wrong
 	affectedRows, _ := result.RowsAffected()
 	if affectedRows == 0 {
-		return fmt.Errorf("0 affected rows, recheck the query:\n\t%s", query)
+		return fmt.Errorf("0 affected rows, value not found: '%s'", valueToUpd)
 	}
 
 	return nil
 }
+
+// add new values to process('Processed' column = NULL)
+//
+// returns list of added values and list of values which are already in db(UNIQUE constraint)
+func (model *DbModel) AddDbValues(values []string) ([]string, []string, error) {
+	added := make([]string, 0, len(values))
+	duplicates := make([]string, 0)
+	postedDate := time.Now().Format(dateLayout)
+
+	for _, value := range values {
+		result, err := model.DB.Exec(model.insertValueQuery, value, postedDate)
+		if err != nil {
+			return added, duplicates, fmt.Errorf("failed to insert value(%s):\n\t%v", value, err)
+		}
+
+		// 0 affected rows means value is already in db
+		affectedRows, _ := result.RowsAffected()
+		if affectedRows == 0 {
+			duplicates = append(duplicates, value)
+			continue
+		}
+		added = append(added, value)
+	}
+
+	return added, duplicates, nil
+}
+
+// reset processed state of values('Processed' column = NULL) to process them again
+//
+// returns list of reset values and list of values not found in db
+func (model *DbModel) ResetDbValues(values []string) ([]string, []string, error) {
+	reset := make([]string, 0, len(values))
+	notFound := make([]string, 0)
+
+	for _, value := range values {
+		result, err := model.DB.Exec(model.resetValueQuery, value)
+		if err != nil {
+			return reset, notFound, fmt.Errorf("failed to reset value(%s):\n\t%v", value, err)
+		}
+
+		affectedRows, _ := result.RowsAffected()
+		if affectedRows == 0 {
+			notFound = append(notFound, value)
+			continue
+		}
+		reset = append(reset, value)
+	}
+
+	return reset, notFound, nil
+}
+
+// save service call(ticket) of value, it's known after value is fetched from helpdesk
+func (model *DbModel) SetDbValueServiceCall(value, serviceCall string) error {
+	result, err := model.DB.Exec(model.updServiceCallQuery, serviceCall, value)
+	if err != nil {
+		return fmt.Errorf("failed to update service call of value(%s):\n\t%v", value, err)
+	}
+
+	affectedRows, _ := result.RowsAffected()
+	if affectedRows == 0 {
+		return fmt.Errorf("0 affected rows, value not found: '%s'", value)
+	}
+
+	return nil
+}
+
+// get service calls of values(value: service call), values which were never fetched are skipped
+func (model *DbModel) GetDbValuesServiceCalls() (map[string]string, error) {
+	result := make(map[string]string)
+
+	rows, err := model.DB.Query(model.selectServiceCallQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make select of service calls of values:\n\t%v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value, serviceCall string
+
+		if err := rows.Scan(&value, &serviceCall); err != nil {
+			return nil, fmt.Errorf("failed to scan rows of select of service calls of values:\n\t%v", err)
+		}
+		result[value] = serviceCall
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,130 @@
+package dboperations
+
+import (
+	"fmt"
+	"time"
+)
+
+// job statuses
+const (
+	JobNew        = "new"
+	JobRunning    = "running"
+	JobDownloaded = "downloaded"
+	JobDelivered  = "delivered"
+	JobFailed     = "failed"
+)
+
+// per-user report job('Jobs' table)
+type Job struct {
+	ID          int64
+	Ticket      string
+	ServiceCall string
+	RP          string
+	Username    string
+	PeriodStart string
+	PeriodEnd   string
+	FazTid      string
+	OutputPath  string
+	Status      string
+	Error       string
+	CreatedDate string
+	UpdatedDate string
+}
+
+// add new job, returns it's id
+func (model *DbModel) AddJob(job Job) (int64, error) {
+	now := time.Now().Format(dateLayout)
+
+	if job.Status == "" {
+		job.Status = JobNew
+	}
+
+	result, err := model.DB.Exec(
+		`INSERT INTO "Jobs" ("Ticket", "Service_Call", "RP", "Username", "Period_Start", "Period_End", "Status", "Created_Date", "Updated_Date")
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.Ticket, job.ServiceCall, job.RP, job.Username, job.PeriodStart, job.PeriodEnd, job.Status, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert job(%s):\n\t%v", job.Username, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get inserted job id(%s):\n\t%v", job.Username, err)
+	}
+
+	return id, nil
+}
+
+// update job status; errText is saved only for failed jobs
+func (model *DbModel) SetJobStatus(id int64, status, errText string) error {
+	return model.updJob(id, `"Status" = ?, "Error" = ?`, status, errText)
+}
+
+// save FAZ report tid of job
+func (model *DbModel) SetJobFazTid(id int64, tid string) error {
+	return model.updJob(id, `"Faz_Tid" = ?`, tid)
+}
+
+// save downloaded report path of job and set it's status to 'downloaded'
+func (model *DbModel) SetJobOutputPath(id int64, path string) error {
+	return model.updJob(id, `"Output_Path" = ?, "Status" = ?`, path, JobDownloaded)
+}
+
+// set status of all jobs of ticket
+func (model *DbModel) SetTicketJobsStatus(ticket, status string) error {
+	_, err := model.DB.Exec(
+		`UPDATE "Jobs" SET "Status" = ?, "Updated_Date" = ? WHERE "Ticket" = ?`,
+		status, time.Now().Format(dateLayout), ticket)
+	if err != nil {
+		return fmt.Errorf("failed to update jobs of ticket(%s):\n\t%v", ticket, err)
+	}
+
+	return nil
+}
+
+// get all jobs of ticket
+func (model *DbModel) GetTicketJobs(ticket string) ([]Job, error) {
+	result := make([]Job, 0)
+
+	rows, err := model.DB.Query(
+		`SELECT "ID", COALESCE("Ticket", ''), COALESCE("Service_Call", ''), COALESCE("RP", ''), "Username",
+		"Period_Start", "Period_End", COALESCE("Faz_Tid", ''), COALESCE("Output_Path", ''), "Status",
+		COALESCE("Error", ''), COALESCE("Created_Date", ''), COALESCE("Updated_Date", '')
+		FROM "Jobs" WHERE "Ticket" = ? ORDER BY "ID"`, ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select jobs of ticket(%s):\n\t%v", ticket, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job Job
+
+		err := rows.Scan(
+			&job.ID, &job.Ticket, &job.ServiceCall, &job.RP, &job.Username,
+			&job.PeriodStart, &job.PeriodEnd, &job.FazTid, &job.OutputPath, &job.Status,
+			&job.Error, &job.CreatedDate, &job.UpdatedDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan jobs of ticket(%s):\n\t%v", ticket, err)
+		}
+		result = append(result, job)
+	}
+
+	return result, rows.Err()
+}
+
+// update job columns(set expression) and it's 'Updated_Date'
+func (model *DbModel) updJob(id int64, set string, args ...any) error {
+	args = append(args, time.Now().Format(dateLayout), id)
+
+	result, err := model.DB.Exec(`UPDATE "Jobs" SET `+set+`, "Updated_Date" = ? WHERE "ID" = ?`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update job(%d):\n\t%v", id, err)
+	}
+
+	affectedRows, _ := result.RowsAffected()
+	if affectedRows == 0 {
+		return fmt.Errorf("0 affected rows, job not found: %d", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package dboperations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// embedded migrations, file name format: <VERSION>_<DESCRIPTION>.sql(ex.: 0001_data.sql)
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migration file data
+type migration struct {
+	Version int
+	Name    string
+	Query   string
+}
+
+// read embedded migrations sorted by version
+func readMigrations() ([]migration, error) {
+	result := make([]migration, 0)
+
+	files, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations dir:\n\t%v", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".sql") {
+			continue
+		}
+
+		versionStr, _, found := strings.Cut(file.Name(), "_")
+		if !found {
+			return nil, fmt.Errorf("wrong migration file name(must be <VERSION>_<DESCRIPTION>.sql): %s", file.Name())
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("wrong migration version(%s):\n\t%v", file.Name(), err)
+		}
+
+		query, err := fs.ReadFile(migrationsFS, "migrations/"+file.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file(%s):\n\t%v", file.Name(), err)
+		}
+
+		result = append(result, migration{Version: version, Name: file.Name(), Query: string(query)})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// get current schema version(0 if no migrations were applied)
+func (model *DbModel) SchemaVersion() (int, error) {
+	var version int
+
+	_, err := model.DB.Exec(`CREATE TABLE IF NOT EXISTS "Schema_Migrations" (
+	"Version"	INTEGER,
+	"Name"	TEXT,
+	"Applied_Date"	TEXT,
+	PRIMARY KEY("Version")
+)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create schema migrations table:\n\t%v", err)
+	}
+
+	err = model.DB.QueryRow(`SELECT COALESCE(MAX("Version"), 0) FROM "Schema_Migrations"`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version:\n\t%v", err)
+	}
+
+	return version, nil
+}
+
+// apply all embedded migrations newer than current schema version
+//
+// returns list of applied migrations names
+func (model *DbModel) Migrate() ([]string, error) {
+	applied := make([]string, 0)
+
+	migrations, err := readMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := model.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		// every migration is applied in it's own transaction
+		tx, err := model.DB.Begin()
+		if err != nil {
+			return applied, fmt.Errorf("failed to begin transaction for migration(%s):\n\t%v", m.Name, err)
+		}
+
+		if _, err := tx.Exec(m.Query); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("failed to apply migration(%s):\n\t%v", m.Name, err)
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO "Schema_Migrations" ("Version", "Name", "Applied_Date") VALUES (?, ?, ?)`,
+			m.Version, m.Name, time.Now().Format(dateLayout))
+		if err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("failed to save migration version(%s):\n\t%v", m.Name, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return applied, fmt.Errorf("failed to commit migration(%s):\n\t%v", m.Name, err)
+		}
+
+		applied = append(applied, m.Name)
+	}
+
+	return applied, nil
+}
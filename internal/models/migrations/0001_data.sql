@@ -0,0 +1,9 @@
+-- table of Naumen task ids(data$...) to process in mode 'naumen'
+CREATE TABLE IF NOT EXISTS "Data" (
+	"ID"	INTEGER,
+	"Value"	TEXT NOT NULL UNIQUE,
+	"Posted_Date"	TEXT,
+	"Processed"	INTEGER,
+	"Processed_Date"	TEXT,
+	PRIMARY KEY("ID")
+);
@@ -0,0 +1,21 @@
+-- table of per-user report jobs
+-- Ticket is Data.Value(data$...) for mode 'naumen' and empty for mode 'csv'
+CREATE TABLE IF NOT EXISTS "Jobs" (
+	"ID"	INTEGER,
+	"Ticket"	TEXT,
+	"Service_Call"	TEXT,
+	"RP"	TEXT,
+	"Username"	TEXT NOT NULL,
+	"Period_Start"	TEXT NOT NULL,
+	"Period_End"	TEXT NOT NULL,
+	"Faz_Tid"	TEXT,
+	"Output_Path"	TEXT,
+	"Status"	TEXT NOT NULL DEFAULT 'new',
+	"Error"	TEXT,
+	"Created_Date"	TEXT,
+	"Updated_Date"	TEXT,
+	PRIMARY KEY("ID")
+);
+
+CREATE INDEX IF NOT EXISTS "Jobs_Ticket" ON "Jobs" ("Ticket");
+CREATE INDEX IF NOT EXISTS "Jobs_Status" ON "Jobs" ("Status");
@@ -0,0 +1,15 @@
+-- table of report files lifecycle: generated -> delivered -> purged
+-- Retain_Until is unix time, delivered file is purged after it
+CREATE TABLE IF NOT EXISTS "Outputs" (
+	"ID"	INTEGER,
+	"Path"	TEXT NOT NULL UNIQUE,
+	"Ticket"	TEXT,
+	"Status"	TEXT NOT NULL DEFAULT 'generated',
+	"Generated_Date"	TEXT,
+	"Delivered_Date"	TEXT,
+	"Retain_Until"	INTEGER,
+	"Purged_Date"	TEXT,
+	PRIMARY KEY("ID")
+);
+
+CREATE INDEX IF NOT EXISTS "Outputs_Status" ON "Outputs" ("Status");
@@ -0,0 +1,2 @@
+-- service call(ticket) of Naumen task id, known after it's fetched: one service call may be added by 'enqueue'(data$...) & found by discovery(serviceCall$...)
+ALTER TABLE "Data" ADD COLUMN "Service_Call" TEXT;
@@ -0,0 +1,122 @@
+package dboperations
+
+import (
+	"fmt"
+	"time"
+)
+
+// output file statuses
+const (
+	OutputGenerated = "generated"
+	OutputDelivered = "delivered"
+	OutputPurged    = "purged"
+)
+
+// report file('Outputs' table)
+type Output struct {
+	ID            int64
+	Path          string
+	Ticket        string
+	Status        string
+	GeneratedDate string
+	DeliveredDate string
+	// unix time, zero if file is not delivered
+	RetainUntil int64
+	PurgedDate  string
+}
+
+// add generated file; file of the same path(ex.: regenerated after failed delivery) starts new lifecycle
+func (model *DbModel) AddOutput(path, ticket string) error {
+	_, err := model.DB.Exec(
+		`INSERT INTO "Outputs" ("Path", "Ticket", "Status", "Generated_Date") VALUES (?, ?, ?, ?)
+		ON CONFLICT ("Path") DO UPDATE SET
+			"Ticket" = excluded."Ticket", "Status" = excluded."Status", "Generated_Date" = excluded."Generated_Date",
+			"Delivered_Date" = NULL, "Retain_Until" = NULL, "Purged_Date" = NULL`,
+		path, ticket, OutputGenerated, time.Now().Format(dateLayout))
+	if err != nil {
+		return fmt.Errorf("failed to insert output(%s):\n\t%v", path, err)
+	}
+
+	return nil
+}
+
+// mark generated files as delivered, they are retained until retainUntil
+func (model *DbModel) SetOutputsDelivered(paths []string, retainUntil time.Time) error {
+	tx, err := model.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction:\n\t%v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Format(dateLayout)
+	for _, path := range paths {
+		_, err := tx.Exec(
+			`UPDATE "Outputs" SET "Status" = ?, "Delivered_Date" = ?, "Retain_Until" = ? WHERE "Path" = ? AND "Status" = ?`,
+			OutputDelivered, now, retainUntil.Unix(), path, OutputGenerated)
+		if err != nil {
+			return fmt.Errorf("failed to update output(%s):\n\t%v", path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction:\n\t%v", err)
+	}
+
+	return nil
+}
+
+// get delivered files which retention is over at now
+func (model *DbModel) GetExpiredOutputs(now time.Time) ([]Output, error) {
+	return model.getOutputs(`"Status" = ? AND "Retain_Until" <= ?`, OutputDelivered, now.Unix())
+}
+
+// get generated(not delivered) files of ticket, ex.: files of previous failed run of ticket
+func (model *DbModel) GetTicketOutputs(ticket string) ([]Output, error) {
+	return model.getOutputs(`"Status" = ? AND "Ticket" = ?`, OutputGenerated, ticket)
+}
+
+// get files by condition(WHERE part with placeholders)
+func (model *DbModel) getOutputs(condition string, args ...any) ([]Output, error) {
+	result := make([]Output, 0)
+
+	rows, err := model.DB.Query(
+		`SELECT "ID", "Path", COALESCE("Ticket", ''), "Status", COALESCE("Generated_Date", ''),
+		COALESCE("Delivered_Date", ''), COALESCE("Retain_Until", 0), COALESCE("Purged_Date", '')
+		FROM "Outputs" WHERE `+condition+` ORDER BY "ID"`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select outputs:\n\t%v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var output Output
+
+		err := rows.Scan(
+			&output.ID, &output.Path, &output.Ticket, &output.Status, &output.GeneratedDate,
+			&output.DeliveredDate, &output.RetainUntil, &output.PurgedDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outputs:\n\t%v", err)
+		}
+		result = append(result, output)
+	}
+
+	return result, rows.Err()
+}
+
+// mark file as purged(deleted from disk)
+func (model *DbModel) SetOutputPurged(id int64) error {
+	result, err := model.DB.Exec(
+		`UPDATE "Outputs" SET "Status" = ?, "Purged_Date" = ? WHERE "ID" = ?`,
+		OutputPurged, time.Now().Format(dateLayout), id)
+	if err != nil {
+		return fmt.Errorf("failed to update output(%d):\n\t%v", id, err)
+	}
+
+	affectedRows, _ := result.RowsAffected()
+	if affectedRows == 0 {
+		return fmt.Errorf("0 affected rows, output not found: %d", id)
+	}
+
+	return nil
+}
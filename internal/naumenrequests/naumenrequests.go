@@ -0,0 +1,238 @@
+package naumenrequests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	naumen "github.com/slayerjk/go-hd-naumen-api"
+)
+
+const (
+	errJsonUnmarshall = "failed to unmarshal response body:\n\t%v\n\t%s"
+	errRequest        = "failed to do request:\n\t%v"
+	errReadResp       = "failed to read response:\n\t%v"
+	errStatusCode     = "bad response status code: %v"
+
+	// prefix of Naumen service call id
+	ServiceCallPrefix = "serviceCall$"
+)
+
+// filter of service calls to discover(naumen-data.json 'naumen-discovery')
+type DiscoveryFilter struct {
+	// service call metaclass, 'serviceCall' by default
+	Metaclass string `json:"metaclass"`
+	// service id, ex.: slmService$1234567
+	Service string `json:"service"`
+	// category id, ex.: catalogs$1234567
+	Category string `json:"category"`
+	// service call states to find, 'registered' by default
+	States []string `json:"states"`
+	// any other attributes to find by
+	ExtraAttrs map[string]string `json:"extra-attrs"`
+}
+
+// characters breaking Naumen find attributes string({key:'value'}), Naumen has no escaping of them
+const attrForbiddenChars = "'{}"
+
+// check filter is not empty and its keys & values can be put into find attributes string
+func (f DiscoveryFilter) Validate() error {
+	if f.Service == "" && f.Category == "" && len(f.ExtraAttrs) == 0 {
+		return fmt.Errorf("discovery filter is empty: set at least service, category or extra-attrs")
+	}
+
+	values := map[string]string{"metaclass": f.Metaclass, "service": f.Service, "category": f.Category}
+	for _, state := range f.States {
+		values["states"] += state
+	}
+	for key, value := range f.ExtraAttrs {
+		values["extra-attrs("+key+")"] = key + value
+	}
+	for name, value := range values {
+		if strings.ContainsAny(value, attrForbiddenChars) {
+			return fmt.Errorf("discovery filter %s must not contain any of %s", name, attrForbiddenChars)
+		}
+	}
+	// metaclass is a path segment of URL
+	if strings.Contains(f.Metaclass, "/") {
+		return fmt.Errorf("discovery filter metaclass must not contain '/'")
+	}
+
+	return nil
+}
+
+// response struct for json body of get
+type getServiceCallResponse struct {
+	UUID           string `json:"UUID"`
+	SumDescription string `json:"sumDescription"`
+	RP             string `json:"title"`
+}
+
+// Find open service calls by discovery filter(GET)
+//
+// Example of full URL:
+//
+// https://{{base_url}}/sd/services/rest/find/serviceCall/{service:'slmService$1234567',state:'registered'}?accessKey={{accessKey}}
+//
+// returns list of service calls ids(serviceCall$...)
+func FindServiceCalls(c *http.Client, baseUrl, accessKey string, filter DiscoveryFilter) ([]string, error) {
+	result := make([]string, 0)
+	seen := make(map[string]bool)
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	metaclass := filter.Metaclass
+	if metaclass == "" {
+		metaclass = "serviceCall"
+	}
+
+	states := filter.States
+	if len(states) == 0 {
+		states = []string{"registered"}
+	}
+
+	// one request per state
+	for _, state := range states {
+		attrs := make(map[string]string)
+		for key, value := range filter.ExtraAttrs {
+			attrs[key] = value
+		}
+		if filter.Service != "" {
+			attrs["service"] = filter.Service
+		}
+		if filter.Category != "" {
+			attrs["categories"] = filter.Category
+		}
+		attrs["state"] = state
+
+		requestURL := fmt.Sprintf(
+			"%s/sd/services/rest/find/%s/%s?accessKey=%s",
+			baseUrl, metaclass, url.PathEscape(formatAttrs(attrs)), url.QueryEscape(accessKey))
+
+		respBody, err := doGet(c, requestURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find service calls(state=%s):\n\t%v", state, err)
+		}
+
+		var found []getServiceCallResponse
+		if err := json.Unmarshal(respBody, &found); err != nil {
+			return nil, fmt.Errorf(errJsonUnmarshall, err, string(respBody))
+		}
+
+		for _, item := range found {
+			if item.UUID == "" || seen[item.UUID] {
+				continue
+			}
+			seen[item.UUID] = true
+			result = append(result, item.UUID)
+		}
+	}
+
+	return result, nil
+}
+
+// Get service call, RP and sumDescription by task id
+//
+// task id may be either Naumen data id(data$...) or service call id(serviceCall$...) found by FindServiceCalls
+//
+// returns []string: ServiceCall, RP, sumDescription json key's value(same as naumen.GetTaskSumDescriptionAndRP)
+func GetTaskSumDescriptionAndRP(c *http.Client, baseUrl, accessKey, taskId string) ([]string, error) {
+	if !strings.HasPrefix(taskId, ServiceCallPrefix) {
+		return naumen.GetTaskSumDescriptionAndRP(c, baseUrl, accessKey, taskId)
+	}
+
+	var respData getServiceCallResponse
+
+	requestURL := fmt.Sprintf("%s/sd/services/rest/get/%s?accessKey=%s", baseUrl, taskId, url.QueryEscape(accessKey))
+
+	respBody, err := doGet(c, requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service call details(%s):\n\t%v", taskId, err)
+	}
+
+	if err := json.Unmarshal(respBody, &respData); err != nil {
+		return nil, fmt.Errorf(errJsonUnmarshall, err, string(respBody))
+	}
+
+	return []string{taskId, respData.RP, respData.SumDescription}, nil
+}
+
+// Add comment to service call(POST)
+//
+// Example of full URL(body is JSON of comment attributes: {"source": "serviceCall$1234567", "text": "..."}):
+//
+// https://{{base_url}}/sd/services/rest/create-m2m/comment?accessKey={{accessKey}}
+func AddComment(c *http.Client, baseUrl, accessKey, serviceCall, text string) error {
+	body, err := json.Marshal(map[string]string{"source": serviceCall, "text": text})
+	if err != nil {
+		return fmt.Errorf("failed to form comment:\n\t%v", err)
+	}
+
+	requestURL := fmt.Sprintf("%s/sd/services/rest/create-m2m/comment?accessKey=%s", baseUrl, url.QueryEscape(accessKey))
+
+	request, err := http.NewRequest(http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to form request:\n\t%v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.Do(request)
+	if err != nil {
+		return fmt.Errorf(errRequest, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 && response.StatusCode != 201 {
+		return fmt.Errorf(errStatusCode, response.Status)
+	}
+
+	return nil
+}
+
+// form Naumen find attributes string: {key1:'value1',key2:'value2'}(sorted by key)
+func formatAttrs(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s:'%s'", key, attrs[key]))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// make GET request and return response body(status must be 200 or 202)
+func doGet(c *http.Client, requestURL string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to form request:\n\t%v", err)
+	}
+
+	response, err := c.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf(errRequest, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 && response.StatusCode != 202 {
+		return nil, fmt.Errorf(errStatusCode, response.Status)
+	}
+
+	respBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf(errReadResp, err)
+	}
+
+	return respBody, nil
+}
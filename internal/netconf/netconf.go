@@ -0,0 +1,247 @@
+package netconf
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// defaults of unset settings
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultReadTimeout    = 2 * time.Minute
+	DefaultRetryAttempts  = 3
+	DefaultRetryDelay     = time.Second
+	DefaultRetryMaxDelay  = 30 * time.Second
+)
+
+// network settings of endpoint(ex.: 'faz-network' of faz-data.json), all are optional
+type Settings struct {
+	// proxy URL: 'http://host:port', 'https://host:port', 'socks5://[user:pass@]host:port';
+	// 'env' - HTTPS_PROXY/HTTP_PROXY/NO_PROXY env vars(HTTP endpoints only); empty - direct connection
+	Proxy string `json:"proxy"`
+	// TCP connect(& TLS handshake) timeout
+	ConnectTimeout Duration `json:"connect-timeout"`
+	// timeout of waiting response(HTTP response headers, every SMTP reply)
+	ReadTimeout Duration `json:"read-timeout"`
+	// retry of idempotent requests only
+	Retry Retry `json:"retry"`
+}
+
+// retry policy: exponential backoff with full jitter
+type Retry struct {
+	// total attempts including the first one(1 - don't retry)
+	Attempts int      `json:"attempts"`
+	Delay    Duration `json:"delay"`
+	MaxDelay Duration `json:"max-delay"`
+}
+
+// duration of JSON: Go duration string('30s', '2m') or number of seconds
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		seconds, errNum := strconv.ParseFloat(string(data), 64)
+		if errNum != nil {
+			return fmt.Errorf("duration must be string('30s') or number of seconds: %s", data)
+		}
+		d.Duration = time.Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Duration.String())
+}
+
+// value of duration or def if it's not set
+func (d Duration) Or(def time.Duration) time.Duration {
+	if d.Duration <= 0 {
+		return def
+	}
+	return d.Duration
+}
+
+// settings with defaults of unset values(ex.: to show effective settings)
+func (s Settings) WithDefaults() Settings {
+	s.ConnectTimeout.Duration = s.ConnectTimeout.Or(DefaultConnectTimeout)
+	s.ReadTimeout.Duration = s.ReadTimeout.Or(DefaultReadTimeout)
+	if s.Retry.Attempts <= 0 {
+		s.Retry.Attempts = DefaultRetryAttempts
+	}
+	s.Retry.Delay.Duration = s.Retry.Delay.Or(DefaultRetryDelay)
+	s.Retry.MaxDelay.Duration = s.Retry.MaxDelay.Or(DefaultRetryMaxDelay)
+	return s
+}
+
+// check proxy URL
+func (s Settings) Validate() error {
+	if s.Proxy == "" || s.Proxy == "env" {
+		return nil
+	}
+
+	proxyUrl, err := url.Parse(s.Proxy)
+	if err != nil {
+		return fmt.Errorf("wrong proxy URL:\n\t%v", err)
+	}
+	switch proxyUrl.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("unsupported proxy scheme(%s): must be http, https or socks5", proxyUrl.Scheme)
+	}
+	if proxyUrl.Host == "" {
+		return fmt.Errorf("proxy host is empty(%s)", s.Proxy)
+	}
+
+	return nil
+}
+
+// proxy function of http.Transport
+func (s Settings) HTTPProxy() func(*http.Request) (*url.URL, error) {
+	switch s.Proxy {
+	case "":
+		return nil
+	case "env":
+		return http.ProxyFromEnvironment
+	}
+
+	proxyUrl, err := url.Parse(s.Proxy)
+	if err != nil {
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(proxyUrl)
+}
+
+// dial TCP address with connect timeout, through proxy if it's set('socks5' or HTTP CONNECT; 'env' isn't supported)
+func (s Settings) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.ConnectTimeout.Or(DefaultConnectTimeout)}
+
+	if s.Proxy == "" {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	if s.Proxy == "env" {
+		return nil, fmt.Errorf("proxy 'env' is supported only for HTTP endpoints")
+	}
+
+	proxyUrl, err := url.Parse(s.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("wrong proxy URL:\n\t%v", err)
+	}
+
+	switch proxyUrl.Scheme {
+	case "socks5":
+		var auth *proxy.Auth
+		if proxyUrl.User != nil {
+			password, _ := proxyUrl.User.Password()
+			auth = &proxy.Auth{User: proxyUrl.User.Username(), Password: password}
+		}
+		socks, err := proxy.SOCKS5("tcp", proxyUrl.Host, auth, dialer)
+		if err != nil {
+			return nil, err
+		}
+		return socks.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	case "http":
+		return s.dialConnect(ctx, dialer, proxyUrl, addr)
+	}
+
+	return nil, fmt.Errorf("unsupported proxy scheme(%s) for %s", proxyUrl.Scheme, addr)
+}
+
+// tunnel to addr through HTTP proxy(CONNECT method)
+func (s Settings) dialConnect(ctx context.Context, dialer *net.Dialer, proxyUrl *url.URL, addr string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", proxyUrl.Host)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(s.ConnectTimeout.Or(DefaultConnectTimeout)))
+
+	request := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", addr, addr)
+	if proxyUrl.User != nil {
+		password, _ := proxyUrl.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyUrl.User.Username() + ":" + password))
+		request += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	if _, err := conn.Write([]byte(request + "\r\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read proxy response:\n\t%v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused CONNECT to %s: %s", addr, response.Status)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	// reader may have read ahead greeting of server(ex.: SMTP)
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// connection which reads buffered data first
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// run fn until it succeeds, it returns not retryable error, attempts are over or ctx is done
+//
+// delay before attempt N is random in [0, min(max-delay, delay * 2^(N-2))]
+func (r Retry) Do(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	attempts := r.Attempts
+	if attempts <= 0 {
+		attempts = DefaultRetryAttempts
+	}
+	delay := r.Delay.Or(DefaultRetryDelay)
+	maxDelay := r.MaxDelay.Or(DefaultRetryMaxDelay)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= attempts || !retryable(err) {
+			return err
+		}
+
+		backoff := delay << (attempt - 1)
+		if backoff > maxDelay || backoff <= 0 {
+			backoff = maxDelay
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(time.Duration(rand.Int63n(int64(backoff) + 1))):
+		}
+	}
+}
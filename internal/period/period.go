@@ -0,0 +1,406 @@
+package period
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FAZ report period format, ex.: '00:00:01 2024/08/06'
+const FazLayout = "15:04:05 2006/01/02"
+
+// accepted datetime formats
+var dateTimeLayouts = []string{
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	FazLayout,
+	"15:04 2006/01/02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// accepted date-only formats(value is expanded to whole day)
+var dateLayouts = []string{
+	"02.01.2006",
+	"2006/01/02",
+	"2006-01-02",
+}
+
+// accepted formats without year(current or previous year, see withYear; end of range follows start)
+var noYearDateTimeLayouts = []string{
+	"02.01 15:04:05",
+	"02.01 15:04",
+}
+
+var noYearDateLayouts = []string{
+	"02.01",
+}
+
+// accepted month formats(value is expanded to whole month)
+var monthLayouts = []string{
+	"2006-01",
+	"01.2006",
+	"2006/01",
+}
+
+var (
+	// 'last 7 days', 'last 12 hours', 'last 2 weeks'
+	reLast = regexp.MustCompile(`(?i)^last\s+(\d+)\s+(hour|day|week|month)s?$`)
+	// explicit time zone at the end of value: 'Z', '+03:00', '+0300', 'UTC', 'Asia/Almaty'
+	reZone = regexp.MustCompile(`^(.+?)\s+(Z|[+-]\d{2}:?\d{2}|UTC|GMT|[A-Za-z]+/[A-Za-z_]+(?:/[A-Za-z_]+)?)$`)
+	// separators of start & end in one value: hyphen with spaces or dash, ex.: '01.07 - 05.07', '01.07–05.07'
+	reRangeSeparator = regexp.MustCompile(`\s+-\s+|\s*[–—]\s*`)
+)
+
+// period start is not before end
+var ErrStartNotBeforeEnd = errors.New("start of period must be before end")
+
+// period is out of FAZ log retention
+var ErrOutOfRetention = errors.New("period is out of FAZ log retention")
+
+// report period, end is inclusive(FAZ)
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// period converted to location
+func (p Period) In(loc *time.Location) Period {
+	return Period{Start: p.Start.In(loc), End: p.End.In(loc)}
+}
+
+// start & end in FAZ format(FAZ has no time zone in period, so convert period to FAZ location first)
+func (p Period) Faz() (string, string) {
+	return p.Start.Format(FazLayout), p.End.Format(FazLayout)
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%s - %s", p.Start.Format("02.01.2006 15:04:05 MST"), p.End.Format("02.01.2006 15:04:05 MST"))
+}
+
+// period parser settings
+type Parser struct {
+	// location of values without explicit time zone(time.Local if nil)
+	Location *time.Location
+	// FAZ log retention, period must not start earlier than now - Retention(not checked if 0)
+	Retention time.Duration
+	// current time(time.Now if nil), for relative periods & retention check
+	Now func() time.Time
+}
+
+func (p Parser) location() *time.Location {
+	if p.Location == nil {
+		return time.Local
+	}
+	return p.Location
+}
+
+func (p Parser) now() time.Time {
+	if p.Now == nil {
+		return time.Now().In(p.location())
+	}
+	return p.Now().In(p.location())
+}
+
+// parse period of values: one value(date, month, relative period or 'start - end') or start & end values
+//
+// empty values are skipped; period is validated
+func (p Parser) Parse(values ...string) (Period, error) {
+	nonEmpty := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			nonEmpty = append(nonEmpty, value)
+		}
+	}
+
+	var (
+		result Period
+		err    error
+	)
+
+	switch len(nonEmpty) {
+	case 0:
+		return Period{}, errors.New("period is empty")
+	case 1:
+		// 'start - end' in one value
+		if parts := reRangeSeparator.Split(nonEmpty[0], -1); len(parts) == 2 {
+			result, err = p.parseRange(parts[0], parts[1])
+		} else {
+			result, err = p.parseSingle(nonEmpty[0])
+		}
+	case 2:
+		result, err = p.parseRange(nonEmpty[0], nonEmpty[1])
+	default:
+		return Period{}, fmt.Errorf("expected start & end of period, got %d values: %v", len(nonEmpty), nonEmpty)
+	}
+	if err != nil {
+		return Period{}, err
+	}
+
+	if err := p.Validate(result); err != nil {
+		return Period{}, err
+	}
+
+	return result, nil
+}
+
+// check start < end & FAZ log retention
+func (p Parser) Validate(period Period) error {
+	if !period.Start.Before(period.End) {
+		return fmt.Errorf("%w: %s", ErrStartNotBeforeEnd, period)
+	}
+
+	if p.Retention > 0 {
+		oldest := p.now().Add(-p.Retention)
+		if period.Start.Before(oldest) {
+			return fmt.Errorf("%w: %s, oldest available start is %s", ErrOutOfRetention, period, oldest.Format("02.01.2006 15:04:05 MST"))
+		}
+	}
+
+	return nil
+}
+
+// start & end values: date-only start is start of day, date-only(month) end is end of day(month)
+func (p Parser) parseRange(startValue, endValue string) (Period, error) {
+	start, err := p.parseValue(startValue)
+	if err != nil {
+		return Period{}, err
+	}
+	end, err := p.parseValue(endValue)
+	if err != nil {
+		return Period{}, err
+	}
+
+	// end without year follows start, ex.: '28.12 - 05.01' ends in next year
+	if p.noYear(endValue) {
+		end.End = followingDate(start.Start, end.End)
+	}
+
+	return Period{Start: start.Start, End: end.End}, nil
+}
+
+// one value: whole day, whole month, relative period or datetime till end of its day
+func (p Parser) parseSingle(value string) (Period, error) {
+	now := p.now()
+
+	switch lower := strings.ToLower(value); {
+	case lower == "today":
+		return Period{Start: startOfDay(now), End: now}, nil
+	case lower == "yesterday":
+		yesterday := startOfDay(now).AddDate(0, 0, -1)
+		return Period{Start: yesterday, End: endOfDay(yesterday)}, nil
+	case reLast.MatchString(value):
+		found := reLast.FindStringSubmatch(value)
+		count, err := strconv.Atoi(found[1])
+		if err != nil || count <= 0 {
+			return Period{}, fmt.Errorf("wrong relative period: %s", value)
+		}
+		return Period{Start: lastStart(now, count, strings.ToLower(found[2])), End: now}, nil
+	}
+
+	result, err := p.parseValue(value)
+	if err != nil {
+		return Period{}, err
+	}
+
+	// single datetime: till end of its day
+	if result.Start.Equal(result.End) {
+		result.End = endOfDay(result.Start)
+	}
+
+	return result, nil
+}
+
+// parse absolute value to period it covers: datetime(start = end), whole day or whole month
+func (p Parser) parseValue(value string) (Period, error) {
+	text, loc, err := p.splitZone(strings.TrimSpace(value))
+	if err != nil {
+		return Period{}, err
+	}
+
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return Period{Start: t, End: t}, nil
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return Period{Start: t, End: endOfDay(t)}, nil
+		}
+	}
+
+	for _, layout := range noYearDateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			t = p.withYear(t)
+			return Period{Start: t, End: t}, nil
+		}
+	}
+
+	for _, layout := range noYearDateLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			t = p.withYear(t)
+			return Period{Start: t, End: endOfDay(t)}, nil
+		}
+	}
+
+	for _, layout := range monthLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return Period{Start: t, End: t.AddDate(0, 1, 0).Add(-time.Second)}, nil
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return Period{Start: t, End: t}, nil
+	}
+
+	return Period{}, fmt.Errorf("unknown date format: %s", value)
+}
+
+// set year to date without year: current or previous one, which is nearer to now(ex.: '25.12' in January is previous year)
+func (p Parser) withYear(t time.Time) time.Time {
+	now := p.now()
+
+	result := time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	if previous := result.AddDate(-1, 0, 0); result.Sub(now) > now.Sub(previous) {
+		result = previous
+	}
+
+	return result
+}
+
+// value is date(time) without year(see withYear)
+func (p Parser) noYear(value string) bool {
+	text, loc, err := p.splitZone(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+
+	for _, layouts := range [][]string{noYearDateTimeLayouts, noYearDateLayouts} {
+		for _, layout := range layouts {
+			if _, err := time.ParseInLocation(layout, text, loc); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// date of year of start, or of next year if its day & month are earlier than ones of start
+func followingDate(start, t time.Time) time.Time {
+	start = start.In(t.Location())
+
+	t = t.AddDate(start.Year()-t.Year(), 0, 0)
+	if t.Month() < start.Month() || (t.Month() == start.Month() && t.Day() < start.Day()) {
+		t = t.AddDate(1, 0, 0)
+	}
+	return t
+}
+
+// cut explicit time zone(separated by space) from value
+func (p Parser) splitZone(value string) (string, *time.Location, error) {
+	found := reZone.FindStringSubmatch(value)
+	if found == nil {
+		return value, p.location(), nil
+	}
+
+	text, zone := found[1], found[2]
+
+	switch {
+	case zone == "Z" || zone == "UTC" || zone == "GMT":
+		return text, time.UTC, nil
+	case strings.Contains(zone, "/"):
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return "", nil, fmt.Errorf("unknown time zone(%s):\n\t%v", zone, err)
+		}
+		return text, loc, nil
+	}
+
+	offset, err := time.Parse("-0700", strings.ReplaceAll(zone, ":", ""))
+	if err != nil {
+		return "", nil, fmt.Errorf("wrong time zone offset: %s", zone)
+	}
+	_, seconds := offset.Zone()
+
+	return text, time.FixedZone(zone, seconds), nil
+}
+
+// units to split period
+const (
+	SplitNone  = ""
+	SplitDay   = "day"
+	SplitWeek  = "week"
+	SplitMonth = "month"
+)
+
+// split period into calendar slices(weeks start on Monday) in period's location
+//
+// first & last slices may be partial; period shorter than unit is returned as is
+func (p Period) Split(unit string) ([]Period, error) {
+	var next func(t time.Time) time.Time
+
+	switch unit {
+	case SplitNone:
+		return []Period{p}, nil
+	case SplitDay:
+		next = func(t time.Time) time.Time { return startOfDay(t).AddDate(0, 0, 1) }
+	case SplitWeek:
+		next = func(t time.Time) time.Time {
+			daysToMonday := (8 - int(t.Weekday())) % 7
+			if daysToMonday == 0 {
+				daysToMonday = 7
+			}
+			return startOfDay(t).AddDate(0, 0, daysToMonday)
+		}
+	case SplitMonth:
+		next = func(t time.Time) time.Time { return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()) }
+	default:
+		return nil, fmt.Errorf("unknown split unit: %s(use '%s', '%s' or '%s')", unit, SplitDay, SplitWeek, SplitMonth)
+	}
+
+	slices := make([]Period, 0)
+	start := p.Start
+	end := p.End.In(p.Start.Location())
+	for start.Before(end) {
+		boundary := next(start)
+		if !boundary.Before(end) {
+			slices = append(slices, Period{Start: start, End: end})
+			break
+		}
+		// end of slice is inclusive
+		slices = append(slices, Period{Start: start, End: boundary.Add(-time.Second)})
+		start = boundary
+	}
+
+	return slices, nil
+}
+
+// start of relative period 'last <count> <unit>'; days, weeks & months are whole days(including today)
+func lastStart(now time.Time, count int, unit string) time.Time {
+	switch unit {
+	case "hour":
+		return now.Add(-time.Duration(count) * time.Hour)
+	case "week":
+		return startOfDay(now).AddDate(0, 0, -7*count+1)
+	case "month":
+		return startOfDay(now).AddDate(0, -count, 1)
+	default:
+		return startOfDay(now).AddDate(0, 0, -count+1)
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func endOfDay(t time.Time) time.Time {
+	return startOfDay(t).AddDate(0, 0, 1).Add(-time.Second)
+}
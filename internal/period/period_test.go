@@ -0,0 +1,267 @@
+package period
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// location of values without time zone & fixed current time of tests
+var (
+	testLocation = time.FixedZone("+05", 5*60*60)
+	testNow      = time.Date(2025, 7, 15, 10, 30, 0, 0, testLocation)
+)
+
+func testParser(retention time.Duration) Parser {
+	return Parser{Location: testLocation, Retention: retention, Now: func() time.Time { return testNow }}
+}
+
+func date(year int, month time.Month, day, hour, min, sec int) time.Time {
+	return time.Date(year, month, day, hour, min, sec, 0, testLocation)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []string
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		// layouts
+		{name: "datetime range", values: []string{"01.07.2025 08:00:00", "02.07.2025 18:00:00"}, wantStart: date(2025, 7, 1, 8, 0, 0), wantEnd: date(2025, 7, 2, 18, 0, 0)},
+		{name: "datetime without seconds", values: []string{"01.07.2025 08:00", "01.07.2025 18:30"}, wantStart: date(2025, 7, 1, 8, 0, 0), wantEnd: date(2025, 7, 1, 18, 30, 0)},
+		{name: "FAZ layout", values: []string{"00:00:01 2025/07/01", "23:59:59 2025/07/01"}, wantStart: date(2025, 7, 1, 0, 0, 1), wantEnd: date(2025, 7, 1, 23, 59, 59)},
+		{name: "ISO datetime", values: []string{"2025-07-01T08:00", "2025-07-01 09:00:00"}, wantStart: date(2025, 7, 1, 8, 0, 0), wantEnd: date(2025, 7, 1, 9, 0, 0)},
+		{name: "date range is whole days", values: []string{"2025/07/01", "2025-07-03"}, wantStart: date(2025, 7, 1, 0, 0, 0), wantEnd: date(2025, 7, 3, 23, 59, 59)},
+		{name: "single date is whole day", values: []string{"01.07.2025"}, wantStart: date(2025, 7, 1, 0, 0, 0), wantEnd: date(2025, 7, 1, 23, 59, 59)},
+		{name: "single datetime till end of day", values: []string{"01.07.2025 12:00"}, wantStart: date(2025, 7, 1, 12, 0, 0), wantEnd: date(2025, 7, 1, 23, 59, 59)},
+		{name: "month", values: []string{"2025-06"}, wantStart: date(2025, 6, 1, 0, 0, 0), wantEnd: date(2025, 6, 30, 23, 59, 59)},
+		{name: "month with dot", values: []string{"02.2025"}, wantStart: date(2025, 2, 1, 0, 0, 0), wantEnd: date(2025, 2, 28, 23, 59, 59)},
+		{name: "date without year", values: []string{"01.07", "05.07"}, wantStart: date(2025, 7, 1, 0, 0, 0), wantEnd: date(2025, 7, 5, 23, 59, 59)},
+		{name: "date without year is nearest to now", values: []string{"25.12 10:00"}, wantStart: date(2025, 12, 25, 10, 0, 0), wantEnd: date(2025, 12, 25, 23, 59, 59)},
+		{name: "empty values are skipped", values: []string{"", "01.07.2025", " "}, wantStart: date(2025, 7, 1, 0, 0, 0), wantEnd: date(2025, 7, 1, 23, 59, 59)},
+
+		// range in one value
+		{name: "hyphen with spaces", values: []string{"01.07.2025 - 03.07.2025"}, wantStart: date(2025, 7, 1, 0, 0, 0), wantEnd: date(2025, 7, 3, 23, 59, 59)},
+		{name: "en dash", values: []string{"01.07–03.07"}, wantStart: date(2025, 7, 1, 0, 0, 0), wantEnd: date(2025, 7, 3, 23, 59, 59)},
+		{name: "em dash with spaces", values: []string{"2025-07-01 — 2025-07-03"}, wantStart: date(2025, 7, 1, 0, 0, 0), wantEnd: date(2025, 7, 3, 23, 59, 59)},
+
+		// relative periods
+		{name: "today", values: []string{"today"}, wantStart: date(2025, 7, 15, 0, 0, 0), wantEnd: testNow},
+		{name: "yesterday", values: []string{"Yesterday"}, wantStart: date(2025, 7, 14, 0, 0, 0), wantEnd: date(2025, 7, 14, 23, 59, 59)},
+		{name: "last days", values: []string{"last 7 days"}, wantStart: date(2025, 7, 9, 0, 0, 0), wantEnd: testNow},
+		{name: "last day", values: []string{"last 1 day"}, wantStart: date(2025, 7, 15, 0, 0, 0), wantEnd: testNow},
+		{name: "last hours", values: []string{"LAST 12 hours"}, wantStart: date(2025, 7, 14, 22, 30, 0), wantEnd: testNow},
+		{name: "last weeks", values: []string{"last 2 weeks"}, wantStart: date(2025, 7, 2, 0, 0, 0), wantEnd: testNow},
+		{name: "last month", values: []string{"last 1 month"}, wantStart: date(2025, 6, 16, 0, 0, 0), wantEnd: testNow},
+
+		// zone suffixes
+		{name: "UTC", values: []string{"01.07.2025 08:00 UTC", "01.07.2025 09:00 Z"}, wantStart: time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC), wantEnd: time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)},
+		{name: "offset", values: []string{"01.07.2025 08:00 +03:00", "01.07.2025 09:00 +0300"}, wantStart: time.Date(2025, 7, 1, 5, 0, 0, 0, time.UTC), wantEnd: time.Date(2025, 7, 1, 6, 0, 0, 0, time.UTC)},
+		{name: "IANA zone", values: []string{"01.07.2025 Europe/Moscow"}, wantStart: time.Date(2025, 6, 30, 21, 0, 0, 0, time.UTC), wantEnd: time.Date(2025, 7, 1, 20, 59, 59, 0, time.UTC)},
+		{name: "RFC3339", values: []string{"2025-07-01T08:00:00Z", "2025-07-01T10:00:00+01:00"}, wantStart: time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC), wantEnd: time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testParser(0).Parse(tt.values...)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.values, err)
+			}
+			if !got.Start.Equal(tt.wantStart) || !got.End.Equal(tt.wantEnd) {
+				t.Errorf("Parse(%q) = %s, want %s", tt.values, got, Period{Start: tt.wantStart, End: tt.wantEnd})
+			}
+		})
+	}
+}
+
+func TestParseWithoutYearInJanuary(t *testing.T) {
+	parser := Parser{Location: testLocation, Now: func() time.Time { return date(2026, 1, 10, 9, 0, 0) }}
+
+	got, err := parser.Parse("25.12", "05.01")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if want := date(2025, 12, 25, 0, 0, 0); !got.Start.Equal(want) {
+		t.Errorf("Parse() start = %s, want previous year %s", got.Start, want)
+	}
+	if want := date(2026, 1, 5, 23, 59, 59); !got.End.Equal(want) {
+		t.Errorf("Parse() end = %s, want current year %s", got.End, want)
+	}
+}
+
+func TestParseRangeWithoutYear(t *testing.T) {
+	tests := []struct {
+		name      string
+		now       time.Time
+		values    []string
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{name: "end in next year, late December", now: date(2025, 12, 30, 9, 0, 0), values: []string{"28.12 - 05.01"}, wantStart: date(2025, 12, 28, 0, 0, 0), wantEnd: date(2026, 1, 5, 23, 59, 59)},
+		{name: "end in next year, mid-year", now: date(2025, 7, 1, 9, 0, 0), values: []string{"28.12", "05.01"}, wantStart: date(2025, 12, 28, 0, 0, 0), wantEnd: date(2026, 1, 5, 23, 59, 59)},
+		{name: "end in year of start", now: date(2025, 12, 30, 9, 0, 0), values: []string{"01.12 - 28.12"}, wantStart: date(2025, 12, 1, 0, 0, 0), wantEnd: date(2025, 12, 28, 23, 59, 59)},
+		{name: "end after start of explicit year", now: date(2025, 7, 1, 9, 0, 0), values: []string{"28.12.2024 10:00", "05.01 18:00"}, wantStart: date(2024, 12, 28, 10, 0, 0), wantEnd: date(2025, 1, 5, 18, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := Parser{Location: testLocation, Now: func() time.Time { return tt.now }}
+
+			got, err := parser.Parse(tt.values...)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if !got.Start.Equal(tt.wantStart) || !got.End.Equal(tt.wantEnd) {
+				t.Errorf("Parse() = %s, want %s", got, Period{Start: tt.wantStart, End: tt.wantEnd})
+			}
+		})
+	}
+
+	// same day: end earlier than start is still an error
+	parser := Parser{Location: testLocation, Now: func() time.Time { return date(2025, 12, 30, 9, 0, 0) }}
+	if _, err := parser.Parse("28.12 10:00", "28.12 09:00"); !errors.Is(err, ErrStartNotBeforeEnd) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrStartNotBeforeEnd)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []string
+		retention time.Duration
+		wantErr   error
+	}{
+		{name: "empty", values: []string{"", " "}},
+		{name: "too many values", values: []string{"01.07.2025", "02.07.2025", "03.07.2025"}},
+		{name: "unknown format", values: []string{"July 1st"}},
+		{name: "unknown zone", values: []string{"01.07.2025 Mars/Olympus"}},
+		{name: "zero relative period", values: []string{"last 0 days"}},
+		{name: "end before start", values: []string{"02.07.2025", "01.07.2025 10:00"}, wantErr: ErrStartNotBeforeEnd},
+		{name: "start equals end", values: []string{"01.07.2025 10:00", "01.07.2025 10:00"}, wantErr: ErrStartNotBeforeEnd},
+		{name: "out of retention", values: []string{"01.06.2025", "02.07.2025"}, retention: 30 * 24 * time.Hour, wantErr: ErrOutOfRetention},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testParser(tt.retention).Parse(tt.values...)
+			if err == nil {
+				t.Fatalf("Parse(%q) error is nil", tt.values)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.values, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetention(t *testing.T) {
+	parser := testParser(30 * 24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		period  Period
+		wantErr bool
+	}{
+		{name: "inside retention", period: Period{Start: date(2025, 6, 16, 0, 0, 0), End: testNow}},
+		{name: "oldest start", period: Period{Start: testNow.Add(-30 * 24 * time.Hour), End: testNow}},
+		{name: "before oldest start", period: Period{Start: testNow.Add(-30*24*time.Hour - time.Second), End: testNow}, wantErr: true},
+		{name: "in other location", period: Period{Start: time.Date(2025, 6, 15, 5, 30, 0, 0, time.UTC), End: testNow}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parser.Validate(tt.period)
+			if tt.wantErr != errors.Is(err, ErrOutOfRetention) {
+				t.Errorf("Validate(%s) error = %v, wantErr %v", tt.period, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := testParser(0).Validate(Period{Start: date(2000, 1, 1, 0, 0, 0), End: testNow}); err != nil {
+		t.Errorf("Validate() without retention error: %v", err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		period Period
+		unit   string
+		want   []Period
+	}{
+		{
+			name:   "no split",
+			period: Period{Start: date(2025, 7, 1, 0, 0, 0), End: date(2025, 7, 10, 23, 59, 59)},
+			unit:   SplitNone,
+			want:   []Period{{Start: date(2025, 7, 1, 0, 0, 0), End: date(2025, 7, 10, 23, 59, 59)}},
+		},
+		{
+			name:   "days with partial first & last",
+			period: Period{Start: date(2025, 7, 1, 12, 0, 0), End: date(2025, 7, 3, 6, 0, 0)},
+			unit:   SplitDay,
+			want: []Period{
+				{Start: date(2025, 7, 1, 12, 0, 0), End: date(2025, 7, 1, 23, 59, 59)},
+				{Start: date(2025, 7, 2, 0, 0, 0), End: date(2025, 7, 2, 23, 59, 59)},
+				{Start: date(2025, 7, 3, 0, 0, 0), End: date(2025, 7, 3, 6, 0, 0)},
+			},
+		},
+		{
+			name:   "weeks start on Monday",
+			period: Period{Start: date(2025, 7, 2, 0, 0, 0), End: date(2025, 7, 20, 23, 59, 59)},
+			unit:   SplitWeek,
+			want: []Period{
+				{Start: date(2025, 7, 2, 0, 0, 0), End: date(2025, 7, 6, 23, 59, 59)},
+				{Start: date(2025, 7, 7, 0, 0, 0), End: date(2025, 7, 13, 23, 59, 59)},
+				{Start: date(2025, 7, 14, 0, 0, 0), End: date(2025, 7, 20, 23, 59, 59)},
+			},
+		},
+		{
+			name:   "week starting on Monday",
+			period: Period{Start: date(2025, 7, 7, 0, 0, 0), End: date(2025, 7, 8, 23, 59, 59)},
+			unit:   SplitWeek,
+			want:   []Period{{Start: date(2025, 7, 7, 0, 0, 0), End: date(2025, 7, 8, 23, 59, 59)}},
+		},
+		{
+			name:   "months",
+			period: Period{Start: date(2025, 1, 15, 0, 0, 0), End: date(2025, 3, 10, 23, 59, 59)},
+			unit:   SplitMonth,
+			want: []Period{
+				{Start: date(2025, 1, 15, 0, 0, 0), End: date(2025, 1, 31, 23, 59, 59)},
+				{Start: date(2025, 2, 1, 0, 0, 0), End: date(2025, 2, 28, 23, 59, 59)},
+				{Start: date(2025, 3, 1, 0, 0, 0), End: date(2025, 3, 10, 23, 59, 59)},
+			},
+		},
+		{
+			name:   "end in other location",
+			period: Period{Start: date(2025, 7, 1, 0, 0, 0), End: time.Date(2025, 7, 1, 20, 0, 0, 0, time.UTC)},
+			unit:   SplitDay,
+			want: []Period{
+				{Start: date(2025, 7, 1, 0, 0, 0), End: date(2025, 7, 1, 23, 59, 59)},
+				{Start: date(2025, 7, 2, 0, 0, 0), End: date(2025, 7, 2, 1, 0, 0)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.period.Split(tt.unit)
+			if err != nil {
+				t.Fatalf("Split(%s) error: %v", tt.unit, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Split(%s) = %v, want %v", tt.unit, got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Start.Equal(tt.want[i].Start) || !got[i].End.Equal(tt.want[i].End) {
+					t.Errorf("Split(%s)[%d] = %s, want %s", tt.unit, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := (Period{Start: testNow, End: testNow.Add(time.Hour)}).Split("year"); err == nil {
+		t.Error("Split(year) error is nil")
+	}
+}
@@ -0,0 +1,234 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// env var with keystore key(base64 of 32 bytes), overrides key file
+const EnvKey = "FAZ_GET_REPORTS_KEYSTORE_KEY"
+
+// version of keystore file format
+const keystoreVersion = 1
+
+// secret is not found in keystore
+var ErrNotFound = errors.New("secret not found in keystore")
+
+// keystore file: secrets encrypted by AES-256-GCM, name of secret is additional data
+type keystoreFile struct {
+	Version int `json:"version"`
+	// name: base64(nonce + ciphertext)
+	Secrets map[string]string `json:"secrets"`
+}
+
+// local encrypted keystore
+//
+// key is kept apart from keystore(key file or env var), so copy of config dir doesn't disclose secrets
+type Keystore struct {
+	path    string
+	keyPath string
+	key     []byte
+	data    keystoreFile
+}
+
+// open keystore(empty one if file doesn't exist yet)
+func OpenKeystore(path, keyPath string) (*Keystore, error) {
+	keystore := &Keystore{
+		path:    path,
+		keyPath: keyPath,
+		data:    keystoreFile{Version: keystoreVersion, Secrets: make(map[string]string)},
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read keystore(%s):\n\t%v", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &keystore.data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal keystore(%s):\n\t%v", path, err)
+		}
+		if keystore.data.Version != keystoreVersion {
+			return nil, fmt.Errorf("unsupported keystore version(%s): %d", path, keystore.data.Version)
+		}
+		if keystore.data.Secrets == nil {
+			keystore.data.Secrets = make(map[string]string)
+		}
+	}
+
+	return keystore, nil
+}
+
+// decrypted secret
+func (k *Keystore) Get(name string) (string, error) {
+	sealed, ok := k.data.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	if err := k.loadKey(false); err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(k.key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("secret %s is corrupted", name)
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s(wrong key?):\n\t%v", name, err)
+	}
+
+	return string(plain), nil
+}
+
+// encrypt & save secret, key file is created if there is no key yet
+func (k *Keystore) Set(name, value string) error {
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("wrong secret name: %q", name)
+	}
+
+	if err := k.loadKey(true); err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(k.key)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce:\n\t%v", err)
+	}
+
+	k.data.Secrets[name] = base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(value), []byte(name)))
+
+	return k.save()
+}
+
+// delete secret, ErrNotFound if there is no such secret
+func (k *Keystore) Delete(name string) error {
+	if _, ok := k.data.Secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(k.data.Secrets, name)
+
+	return k.save()
+}
+
+// names of secrets, sorted
+func (k *Keystore) Names() []string {
+	names := make([]string, 0, len(k.data.Secrets))
+	for name := range k.data.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// write keystore file(owner only), temp file is renamed to keep old keystore on failure
+func (k *Keystore) save() error {
+	content, err := json.MarshalIndent(k.data, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal keystore:\n\t%v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0700); err != nil {
+		return fmt.Errorf("failed to create keystore dir(%s):\n\t%v", filepath.Dir(k.path), err)
+	}
+
+	tmpPath := k.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
+		return fmt.Errorf("failed to write keystore(%s):\n\t%v", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, k.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save keystore(%s):\n\t%v", k.path, err)
+	}
+
+	return nil
+}
+
+// read key from env var or key file, create key file if create is set and there is no key
+func (k *Keystore) loadKey(create bool) error {
+	if k.key != nil {
+		return nil
+	}
+
+	encoded := os.Getenv(EnvKey)
+	source := EnvKey
+
+	if encoded == "" {
+		source = k.keyPath
+		content, err := os.ReadFile(k.keyPath)
+		switch {
+		// new key only for empty keystore: secrets of lost key can't be decrypted anyway
+		case errors.Is(err, fs.ErrNotExist) && create && len(k.data.Secrets) == 0:
+			return k.createKey()
+		case errors.Is(err, fs.ErrNotExist):
+			return fmt.Errorf("keystore key is not found: neither %s nor key file(%s) is set", EnvKey, k.keyPath)
+		case err != nil:
+			return fmt.Errorf("failed to read keystore key(%s):\n\t%v", k.keyPath, err)
+		}
+		encoded = string(content)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return fmt.Errorf("wrong keystore key(%s): must be base64 of 32 bytes", source)
+	}
+	k.key = key
+
+	return nil
+}
+
+// generate new key and write it to key file(owner only)
+func (k *Keystore) createKey() error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate keystore key:\n\t%v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(k.keyPath), 0700); err != nil {
+		return fmt.Errorf("failed to create keystore key dir(%s):\n\t%v", filepath.Dir(k.keyPath), err)
+	}
+
+	// O_EXCL: never overwrite key of existing secrets
+	file, err := os.OpenFile(k.keyPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create keystore key(%s):\n\t%v", k.keyPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		return fmt.Errorf("failed to write keystore key(%s):\n\t%v", k.keyPath, err)
+	}
+	k.key = key
+
+	return file.Sync()
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher:\n\t%v", err)
+	}
+	return cipher.NewGCM(block)
+}
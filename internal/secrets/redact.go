@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// replacement of redacted secrets
+const Mask = "***"
+
+// shorter values are not redacted: they'd mask random parts of text
+const minRedactLen = 4
+
+// registered secrets(resolved credentials, session ids...)
+var registry struct {
+	sync.RWMutex
+	replacer *strings.Replacer
+	values   map[string]bool
+}
+
+// register secret values to be redacted by Redact & redacting log handler
+//
+// JSON escaped forms of values are registered too: errors may contain JSON request & response bodies
+func Register(values ...string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.values == nil {
+		registry.values = make(map[string]bool)
+	}
+
+	changed := false
+	for _, value := range values {
+		for _, form := range jsonForms(value) {
+			if len(form) < minRedactLen || registry.values[form] {
+				continue
+			}
+			registry.values[form] = true
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	// longer values first: secret may contain another one
+	sorted := make([]string, 0, len(registry.values))
+	for value := range registry.values {
+		sorted = append(sorted, value)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	pairs := make([]string, 0, len(sorted)*2)
+	for _, value := range sorted {
+		pairs = append(pairs, value, Mask)
+	}
+	registry.replacer = strings.NewReplacer(pairs...)
+}
+
+// value & its forms inside JSON string(ex.: '"' is '\"', '<' is '\u003c' by default & as is without HTML escaping)
+func jsonForms(value string) []string {
+	forms := []string{value}
+	for _, escapeHTML := range []bool{true, false} {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(escapeHTML)
+		if err := encoder.Encode(value); err != nil {
+			continue
+		}
+		quoted := strings.TrimSuffix(buf.String(), "\n")
+		forms = append(forms, strings.TrimSuffix(strings.TrimPrefix(quoted, `"`), `"`))
+	}
+	return forms
+}
+
+// text with registered secrets replaced by Mask
+func Redact(text string) string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if registry.replacer == nil {
+		return text
+	}
+	return registry.replacer.Replace(text)
+}
+
+// slog handler redacting registered secrets in messages & attributes
+type redactHandler struct {
+	next slog.Handler
+}
+
+func NewRedactHandler(next slog.Handler) slog.Handler {
+	return &redactHandler{next: next}
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, redactAttr(attr))
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+// strings, errors & other values(formatted as by text handler) are redacted
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(value.String()))
+	case slog.KindAny:
+		return slog.String(attr.Key, Redact(fmt.Sprintf("%+v", value.Any())))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, 0, len(group))
+		for _, groupAttr := range group {
+			redacted = append(redacted, redactAttr(groupAttr))
+		}
+		return slog.Group(attr.Key, redacted...)
+	}
+
+	return slog.Attr{Key: attr.Key, Value: value}
+}
@@ -0,0 +1,41 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRedactJson(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{name: "plain", secret: "Plain-Pass1"},
+		{name: "quote & backslash", secret: `pa"ss\word`},
+		{name: "html characters", secret: "p<a>ss&word"},
+		{name: "control characters", secret: "pass\tword\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Register(tt.secret)
+
+			// ex.: request body in error of FAZ login
+			body, err := json.Marshal(map[string]string{"passwd": tt.secret})
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := fmt.Sprintf("failed to login:\n\t%s\n\t%s", body, tt.secret)
+
+			got := Redact(text)
+			if strings.Contains(got, string(body)) || strings.Contains(got, tt.secret) {
+				t.Errorf("Redact() = %q, secret is not redacted", got)
+			}
+			if want := fmt.Sprintf(`{"passwd":"%s"}`, Mask); !strings.Contains(got, want) {
+				t.Errorf("Redact() = %q, want %s in it", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefixes of secret references in data files
+const (
+	// value of env var, ex.: 'env:FAZ_PASS'
+	PrefixEnv = "env:"
+	// content of file(trailing new line is trimmed), ex.: 'file:/run/secrets/faz-pass'
+	PrefixFile = "file:"
+	// value of local encrypted keystore(see Keystore), ex.: 'keystore:faz-pass'
+	PrefixKeystore = "keystore:"
+)
+
+// referenced env var is not set or is empty
+var ErrEmpty = errors.New("secret is empty")
+
+// resolver of secret references, keystore is opened on first 'keystore:' reference
+type Resolver struct {
+	KeystorePath string
+	KeyPath      string
+
+	keystore *Keystore
+}
+
+// value of reference('env:', 'file:', 'keystore:'), any other value is returned as is
+//
+// resolved secrets are registered for redaction(see Redact)
+func (r *Resolver) Resolve(value string) (string, error) {
+	var (
+		result string
+		err    error
+	)
+
+	switch {
+	case strings.HasPrefix(value, PrefixEnv):
+		name := strings.TrimPrefix(value, PrefixEnv)
+		result = os.Getenv(name)
+		if result == "" {
+			return "", fmt.Errorf("%w: env var %s is not set", ErrEmpty, name)
+		}
+	case strings.HasPrefix(value, PrefixFile):
+		path := strings.TrimPrefix(value, PrefixFile)
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			return "", fmt.Errorf("failed to read secret file(%s):\n\t%v", path, errRead)
+		}
+		result = strings.TrimRight(string(data), "\r\n")
+		if result == "" {
+			return "", fmt.Errorf("%w: secret file %s is empty", ErrEmpty, path)
+		}
+	case strings.HasPrefix(value, PrefixKeystore):
+		if r.keystore == nil {
+			r.keystore, err = OpenKeystore(r.KeystorePath, r.KeyPath)
+			if err != nil {
+				return "", err
+			}
+		}
+		result, err = r.keystore.Get(strings.TrimPrefix(value, PrefixKeystore))
+		if err != nil {
+			return "", err
+		}
+	default:
+		result = value
+	}
+
+	Register(result)
+
+	return result, nil
+}
+
+// forget opened keystore, it's opened again on next 'keystore:' reference(ex.: reload of config after 'secrets set')
+func (r *Resolver) Reset() {
+	r.keystore = nil
+}
+
+// resolve references of several fields in place, error names the field
+func (r *Resolver) ResolveFields(fields map[string]*string) error {
+	for name, field := range fields {
+		if *field == "" {
+			continue
+		}
+		value, err := r.Resolve(*field)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		*field = value
+	}
+	return nil
+}
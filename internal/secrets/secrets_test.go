@@ -0,0 +1,44 @@
+package secrets
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolverReset(t *testing.T) {
+	dir := t.TempDir()
+	keystorePath, keyPath := filepath.Join(dir, "keystore.json"), filepath.Join(dir, "keystore.key")
+	t.Setenv(EnvKey, "")
+
+	setSecret := func(value string) {
+		t.Helper()
+		keystore, err := OpenKeystore(keystorePath, keyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := keystore.Set("faz-pass", value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resolve := func(resolver *Resolver, want string) {
+		t.Helper()
+		got, err := resolver.Resolve(PrefixKeystore + "faz-pass")
+		if err != nil {
+			t.Fatalf("Resolve() error: %v", err)
+		}
+		if got != want {
+			t.Errorf("Resolve() = %s, want %s", got, want)
+		}
+	}
+
+	setSecret("old-pass")
+	resolver := &Resolver{KeystorePath: keystorePath, KeyPath: keyPath}
+	resolve(resolver, "old-pass")
+
+	// 'secrets set' of running instance: opened keystore is used till reset(reload)
+	setSecret("new-pass")
+	resolve(resolver, "old-pass")
+
+	resolver.Reset()
+	resolve(resolver, "new-pass")
+}
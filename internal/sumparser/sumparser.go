@@ -0,0 +1,342 @@
+package sumparser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// fields of Naumen request form
+const (
+	FieldUsers = "users"
+	FieldDates = "dates"
+)
+
+// default users separators: comma, semicolon & new line
+const defaultUsersSeparators = ",;\n"
+
+// default separator of start & end dates
+const defaultDatesSeparator = " - "
+
+// form template: how to find fields in sumDescription of Naumen request
+//
+// every field is found either by labels(text before the field value, ex.: 'Укажите учетную запись:')
+// or by regexp pattern with one capture group applied to sumDescription plain text
+type Template struct {
+	Name string `json:"name"`
+	// template is applied only if sumDescription plain text matches this regexp(optional)
+	Match        string   `json:"match"`
+	UsersLabels  []string `json:"users-labels"`
+	UsersPattern string   `json:"users-pattern"`
+	DatesLabels  []string `json:"dates-labels"`
+	DatesPattern string   `json:"dates-pattern"`
+	// characters separating users, ",;\n" by default
+	UsersSeparators string `json:"users-separators"`
+	// separator of start & end dates, " - " by default
+	DatesSeparator string `json:"dates-separator"`
+}
+
+// built-in template of current HD Naumen form
+var DefaultTemplate = Template{
+	Name:        "default",
+	UsersLabels: []string{"Укажите учетную запись"},
+	DatesLabels: []string{"Укажите дату"},
+}
+
+// parsed Naumen request
+type Request struct {
+	// name of template used
+	Template string
+	Users    []string
+	// start & end dates as in request(not parsed), common for users without own dates
+	Dates []string
+	// own dates of users(ex.: 'USER1: 01.07-05.07; USER2: 10.07-12.07'), not parsed
+	UserDates map[string][]string
+}
+
+// field of ticket is not found or is empty
+type FieldError struct {
+	Ticket   string
+	Field    string
+	Template string
+	Reason   string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("ticket %s: field '%s' %s(template '%s')", e.Ticket, e.Field, e.Reason, e.Template)
+}
+
+// none of templates matched sumDescription
+var ErrNoTemplate = errors.New("no matching template")
+
+// check templates regexps, must be called once before Parse
+func Validate(templates []Template) error {
+	for _, t := range templates {
+		for _, pattern := range []string{t.Match, t.UsersPattern, t.DatesPattern} {
+			if pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("wrong pattern of template '%s':\n\t%v", t.Name, err)
+			}
+			if pattern != t.Match && re.NumSubexp() != 1 {
+				return fmt.Errorf("pattern of template '%s' must have exactly one capture group: %s", t.Name, pattern)
+			}
+		}
+		if len(t.UsersLabels) == 0 && t.UsersPattern == "" {
+			return fmt.Errorf("template '%s' has neither users-labels nor users-pattern", t.Name)
+		}
+		if len(t.DatesLabels) == 0 && t.DatesPattern == "" {
+			return fmt.Errorf("template '%s' has neither dates-labels nor dates-pattern", t.Name)
+		}
+	}
+
+	return nil
+}
+
+// parse sumDescription(HTML) of ticket using first matching template(DefaultTemplate if templates are empty)
+//
+// error of the first applicable template is returned if no template fits
+func Parse(ticket, sumDescription string, templates []Template) (*Request, error) {
+	if len(templates) == 0 {
+		templates = []Template{DefaultTemplate}
+	}
+
+	lines, err := textLines(sumDescription)
+	if err != nil {
+		return nil, fmt.Errorf("ticket %s: failed to parse sumDescription HTML:\n\t%v", ticket, err)
+	}
+	text := strings.Join(lines, "\n")
+
+	var firstErr error
+	for _, t := range templates {
+		if t.Match != "" && !regexp.MustCompile(t.Match).MatchString(text) {
+			continue
+		}
+
+		request, err := t.parse(ticket, lines, text)
+		if err == nil {
+			return request, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr == nil {
+		return nil, fmt.Errorf("ticket %s: %w", ticket, ErrNoTemplate)
+	}
+
+	return nil, firstErr
+}
+
+// parse fields with template
+func (t Template) parse(ticket string, lines []string, text string) (*Request, error) {
+	fieldErr := func(field, reason string) error {
+		return &FieldError{Ticket: ticket, Field: field, Template: t.Name, Reason: reason}
+	}
+
+	// all labels of template: value of a field ends on the line with another label
+	allLabels := append(append([]string{}, t.UsersLabels...), t.DatesLabels...)
+
+	// users
+	usersValue, found := t.field(lines, text, t.UsersLabels, t.UsersPattern, allLabels)
+	if !found {
+		return nil, fieldErr(FieldUsers, "not found")
+	}
+	separators := t.UsersSeparators
+	if separators == "" {
+		separators = defaultUsersSeparators
+	}
+	users := make([]string, 0)
+	userDates := make(map[string][]string)
+	for _, user := range strings.FieldsFunc(usersValue, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+		if user = strings.TrimSpace(user); user == "" {
+			continue
+		}
+
+		// user with own dates: 'USER1: 01.07-05.07'
+		if found := reUserDates.FindStringSubmatch(user); found != nil {
+			user = found[1]
+			userDates[user] = t.splitDates(found[2])
+		}
+
+		users = append(users, user)
+	}
+	if len(users) == 0 {
+		return nil, fieldErr(FieldUsers, "is empty")
+	}
+
+	// dates are required only if some user has no own dates
+	withoutDates := 0
+	for _, user := range users {
+		if len(userDates[user]) == 0 {
+			withoutDates++
+		}
+	}
+
+	// dates
+	datesValue, found := t.field(lines, text, t.DatesLabels, t.DatesPattern, allLabels)
+	if !found && withoutDates != 0 {
+		return nil, fieldErr(FieldDates, "not found")
+	}
+	dates := t.splitDates(datesValue)
+	if len(dates) == 0 && withoutDates != 0 {
+		return nil, fieldErr(FieldDates, "is empty")
+	}
+
+	return &Request{Template: t.Name, Users: users, Dates: dates, UserDates: userDates}, nil
+}
+
+// split dates value by dates separator, empty dates are skipped
+func (t Template) splitDates(value string) []string {
+	datesSeparator := t.DatesSeparator
+	if datesSeparator == "" {
+		datesSeparator = defaultDatesSeparator
+	}
+
+	dates := make([]string, 0, 2)
+	for _, date := range strings.Split(strings.ReplaceAll(value, "\n", " "), datesSeparator) {
+		if date = strings.TrimSpace(date); date != "" {
+			dates = append(dates, date)
+		}
+	}
+
+	// compact range without dates separator: '01.07-05.07', '01.07.2025–05.07.2025'
+	if len(dates) == 1 {
+		if found := reCompactRange.FindStringSubmatch(dates[0]); found != nil {
+			dates = []string{found[1], found[2]}
+		}
+	}
+
+	return dates
+}
+
+// compact range of dates('dd.mm' or 'dd.mm.yyyy', optional time) separated by hyphen or dash, ex.: '01.07-05.07', '01.07.2025 10:00 – 05.07.2025'
+var reCompactRange = regexp.MustCompile(`^(\d{1,2}\.\d{1,2}(?:\.\d{4})?(?:\s+\d{1,2}:\d{2}(?::\d{2})?)?)\s*[-–—]\s*(\d{1,2}\.\d{1,2}(?:\.\d{4})?(?:\s+\d{1,2}:\d{2}(?::\d{2})?)?)$`)
+
+// find field value by pattern(if set) or by labels
+//
+// value of label is the rest of label's line plus next lines until line with another label(of template or any other)
+func (t Template) field(lines []string, text string, labels []string, pattern string, allLabels []string) (string, bool) {
+	if pattern != "" {
+		found := regexp.MustCompile(pattern).FindStringSubmatch(text)
+		if found == nil {
+			return "", false
+		}
+		return strings.TrimSpace(found[1]), true
+	}
+
+	for _, label := range labels {
+		reLabel := labelRegexp(label)
+
+		for ind, line := range lines {
+			loc := reLabel.FindStringIndex(line)
+			if loc == nil {
+				continue
+			}
+
+			// another label on the same line ends the value
+			rest := line[loc[1]:]
+			if end := labelIndex(rest, allLabels); end >= 0 {
+				return strings.TrimSpace(rest[:end]), true
+			}
+
+			value := []string{rest}
+			for _, next := range lines[ind+1:] {
+				if hasLabel(next, allLabels) || reAnyLabel.MatchString(next) && !reUserDates.MatchString(next) && !reGroupEntry.MatchString(next) {
+					break
+				}
+				value = append(value, next)
+			}
+
+			return strings.TrimSpace(strings.Join(value, "\n")), true
+		}
+	}
+
+	return "", false
+}
+
+// line starting with any label(words followed by colon), ex.: 'Комментарий: ...'
+var reAnyLabel = regexp.MustCompile(`^\p{L}[\p{L}\s]*:`)
+
+// user with own dates(account, colon, date), ex.: 'USER1: 01.07-05.07'
+var reUserDates = regexp.MustCompile(`^([^\s:]+)\s*:\s*(\d{1,4}[./-]\d.*)$`)
+
+// AD group entry of users list, ex.: 'group:VPN-Contractors'
+var reGroupEntry = regexp.MustCompile(`(?i)^group\s*:`)
+
+// label regexp: case insensitive, any number of colons & spaces after label
+func labelRegexp(label string) *regexp.Regexp {
+	label = strings.TrimRight(strings.TrimSpace(label), ":")
+	return regexp.MustCompile(`(?i)` + regexp.QuoteMeta(label) + `\s*:*\s*`)
+}
+
+func hasLabel(line string, labels []string) bool {
+	return labelIndex(line, labels) >= 0
+}
+
+// index of the first label in line, -1 if there is no label
+func labelIndex(line string, labels []string) int {
+	result := -1
+	for _, label := range labels {
+		if loc := labelRegexp(label).FindStringIndex(line); loc != nil && (result < 0 || loc[0] < result) {
+			result = loc[0]
+		}
+	}
+	return result
+}
+
+// convert HTML to plain text lines(block elements & <br> start new line), empty lines are skipped
+func textLines(sumDescription string) ([]string, error) {
+	doc, err := html.Parse(strings.NewReader(sumDescription))
+	if err != nil {
+		return nil, err
+	}
+
+	var builder strings.Builder
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			builder.WriteString(node.Data)
+		case html.ElementNode:
+			switch node.Data {
+			case "br", "p", "div", "li", "tr", "table", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6":
+				builder.WriteString("\n")
+			case "td", "th":
+				builder.WriteString(" ")
+			case "script", "style":
+				return
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "p", "div", "li", "tr", "table", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6":
+				builder.WriteString("\n")
+			}
+		}
+	}
+	walk(doc)
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(builder.String(), "\n") {
+		// non-breaking spaces are common in HD forms
+		line = strings.TrimSpace(strings.ReplaceAll(line, " ", " "))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,245 @@
+package sumparser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// sumDescription of current HD Naumen form
+const defaultForm = `<font color="#5f5f5f">Укажите учетную запись: <b>MAMYRBDA, MARCHENM</b></font><br>` +
+	`<font color="#5f5f5f">Укажите дату:: <b>31.07.2025 00:59 - 31.07.2025 19:00</b></font><br>`
+
+func TestParse(t *testing.T) {
+	patternTemplate := Template{
+		Name:           "vpn",
+		Match:          `VPN`,
+		UsersPattern:   `Логины:\s*(.+)`,
+		DatesPattern:   `Период:\s*(.+)`,
+		DatesSeparator: " по ",
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		templates []Template
+		want      *Request
+	}{
+		{
+			name: "default template",
+			text: defaultForm,
+			want: &Request{
+				Template:  "default",
+				Users:     []string{"MAMYRBDA", "MARCHENM"},
+				Dates:     []string{"31.07.2025 00:59", "31.07.2025 19:00"},
+				UserDates: map[string][]string{},
+			},
+		},
+		{
+			name: "users on several lines",
+			text: `<p>Укажите учетную запись:</p><p>USER1;</p><p>USER2</p><p>Укажите дату: 01.07.2025 - 05.07.2025</p>`,
+			want: &Request{
+				Template:  "default",
+				Users:     []string{"USER1", "USER2"},
+				Dates:     []string{"01.07.2025", "05.07.2025"},
+				UserDates: map[string][]string{},
+			},
+		},
+		{
+			name: "fields on one line",
+			text: `Укажите учетную запись: USER1 Укажите дату: 01.07.2025`,
+			want: &Request{
+				Template:  "default",
+				Users:     []string{"USER1"},
+				Dates:     []string{"01.07.2025"},
+				UserDates: map[string][]string{},
+			},
+		},
+		{
+			name: "other label ends value",
+			text: `<div>Укажите учетную запись: USER1</div><div>Комментарий: срочно</div><div>Укажите дату: 01.07.2025</div>`,
+			want: &Request{
+				Template:  "default",
+				Users:     []string{"USER1"},
+				Dates:     []string{"01.07.2025"},
+				UserDates: map[string][]string{},
+			},
+		},
+		{
+			name: "AD group entry",
+			text: `<p>Укажите учетную запись:</p><p>USER1</p><p>group:VPN-Contractors</p><p>Укажите дату: 01.07.2025</p>`,
+			want: &Request{
+				Template:  "default",
+				Users:     []string{"USER1", "group:VPN-Contractors"},
+				Dates:     []string{"01.07.2025"},
+				UserDates: map[string][]string{},
+			},
+		},
+		{
+			name:      "pattern template",
+			text:      `<p>Доступ VPN</p><p>Логины: USERA, USERB</p><p>Период: 01.07.2025 по 02.07.2025</p>`,
+			templates: []Template{patternTemplate, DefaultTemplate},
+			want: &Request{
+				Template:  "vpn",
+				Users:     []string{"USERA", "USERB"},
+				Dates:     []string{"01.07.2025", "02.07.2025"},
+				UserDates: map[string][]string{},
+			},
+		},
+		{
+			name:      "not matching template is skipped",
+			text:      defaultForm,
+			templates: []Template{patternTemplate, DefaultTemplate},
+			want: &Request{
+				Template:  "default",
+				Users:     []string{"MAMYRBDA", "MARCHENM"},
+				Dates:     []string{"31.07.2025 00:59", "31.07.2025 19:00"},
+				UserDates: map[string][]string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse("data$1", tt.text, tt.templates)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		templates []Template
+		field     string
+	}{
+		{name: "no users label", text: `Укажите дату: 01.07.2025`, field: FieldUsers},
+		{name: "empty users", text: `Укажите учетную запись: ;,<br>Укажите дату: 01.07.2025`, field: FieldUsers},
+		{name: "no dates label", text: `Укажите учетную запись: USER1`, field: FieldDates},
+		{name: "empty dates", text: `Укажите учетную запись: USER1<br>Укажите дату: <b> </b>`, field: FieldDates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("data$1", tt.text, tt.templates)
+
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("Parse() error = %v, want FieldError", err)
+			}
+			if fieldErr.Field != tt.field || fieldErr.Ticket != "data$1" {
+				t.Errorf("Parse() error = %+v, want field %s of data$1", fieldErr, tt.field)
+			}
+		})
+	}
+
+	t.Run("no matching template", func(t *testing.T) {
+		_, err := Parse("data$1", defaultForm, []Template{{Name: "vpn", Match: `VPN`, UsersPattern: `(.+)`, DatesPattern: `(.+)`}})
+		if !errors.Is(err, ErrNoTemplate) {
+			t.Errorf("Parse() error = %v, want ErrNoTemplate", err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template Template
+		wantErr  bool
+	}{
+		{name: "labels", template: DefaultTemplate},
+		{name: "patterns", template: Template{Name: "p", Match: `VPN`, UsersPattern: `Логины:(.+)`, DatesPattern: `Период:(.+)`}},
+		{name: "wrong regexp", template: Template{Name: "p", UsersPattern: `(`, DatesLabels: []string{"Дата"}}, wantErr: true},
+		{name: "no capture group", template: Template{Name: "p", UsersPattern: `Логины:.+`, DatesLabels: []string{"Дата"}}, wantErr: true},
+		{name: "two capture groups", template: Template{Name: "p", UsersPattern: `(Логины):(.+)`, DatesLabels: []string{"Дата"}}, wantErr: true},
+		{name: "no users field", template: Template{Name: "p", DatesLabels: []string{"Дата"}}, wantErr: true},
+		{name: "no dates field", template: Template{Name: "p", UsersLabels: []string{"Логин"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate([]Template{tt.template}); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseUserDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantUsers []string
+		wantDates []string
+		wantOwn   map[string][]string
+	}{
+		{
+			name:      "ASCII hyphen",
+			text:      `<p>Укажите учетную запись:</p><p>USER1: 01.07-05.07</p>`,
+			wantUsers: []string{"USER1"},
+			wantDates: []string{},
+			wantOwn:   map[string][]string{"USER1": {"01.07", "05.07"}},
+		},
+		{
+			name:      "en dash with year",
+			text:      `<p>Укажите учетную запись:</p><p>USER1: 01.07.2025–05.07.2025</p>`,
+			wantUsers: []string{"USER1"},
+			wantDates: []string{},
+			wantOwn:   map[string][]string{"USER1": {"01.07.2025", "05.07.2025"}},
+		},
+		{
+			name:      "spaced forms",
+			text:      `<p>Укажите учетную запись:</p><p>USER1: 01.07 - 05.07</p><p>USER2: 10.07.2025 10:00 – 12.07.2025 18:00</p>`,
+			wantUsers: []string{"USER1", "USER2"},
+			wantDates: []string{},
+			wantOwn:   map[string][]string{"USER1": {"01.07", "05.07"}, "USER2": {"10.07.2025 10:00", "12.07.2025 18:00"}},
+		},
+		{
+			name:      "users with & without own dates",
+			text:      `<p>Укажите учетную запись:</p><p>USER1: 01.07-05.07;</p><p>USER2</p><p>Укажите дату: 20.07.2025-21.07.2025</p>`,
+			wantUsers: []string{"USER1", "USER2"},
+			wantDates: []string{"20.07.2025", "21.07.2025"},
+			wantOwn:   map[string][]string{"USER1": {"01.07", "05.07"}},
+		},
+		{
+			name:      "single own date",
+			text:      `<p>Укажите учетную запись:</p><p>USER1: 01.07.2025</p>`,
+			wantUsers: []string{"USER1"},
+			wantDates: []string{},
+			wantOwn:   map[string][]string{"USER1": {"01.07.2025"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse("data$1", tt.text, nil)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if !reflect.DeepEqual(got.Users, tt.wantUsers) {
+				t.Errorf("Parse() users = %q, want %q", got.Users, tt.wantUsers)
+			}
+			if !reflect.DeepEqual(got.Dates, tt.wantDates) {
+				t.Errorf("Parse() dates = %q, want %q", got.Dates, tt.wantDates)
+			}
+			if !reflect.DeepEqual(got.UserDates, tt.wantOwn) {
+				t.Errorf("Parse() user dates = %q, want %q", got.UserDates, tt.wantOwn)
+			}
+		})
+	}
+
+	t.Run("user without dates", func(t *testing.T) {
+		_, err := Parse("data$1", `<p>Укажите учетную запись:</p><p>USER1: 01.07-05.07</p><p>USER2</p>`, nil)
+
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != FieldDates {
+			t.Errorf("Parse() error = %v, want FieldError of dates", err)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package ticketing
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	naumenreq "github.com/slayerjk/faz-get-reports/internal/naumenrequests"
+	naumen "github.com/slayerjk/go-hd-naumen-api"
+)
+
+// Naumen helpdesk API
+//
+// Naumen attaches files only with setting acceptance: Attach collects files, Resolve sends them;
+// Naumen has no common reject transition(it depends on workflow), so Reject isn't supported(ErrNotConfigured)
+type Naumen struct {
+	client    *http.Client
+	baseUrl   string
+	accessKey string
+	discovery naumenreq.DiscoveryFilter
+
+	mu sync.Mutex
+	// files to attach on resolve by service call
+	files map[string][]string
+}
+
+func NewNaumen(c *http.Client, baseUrl, accessKey string, discovery naumenreq.DiscoveryFilter) *Naumen {
+	return &Naumen{
+		client:    c,
+		baseUrl:   baseUrl,
+		accessKey: accessKey,
+		discovery: discovery,
+		files:     make(map[string][]string),
+	}
+}
+
+func (n *Naumen) Fetch(id string) (Request, error) {
+	sumDescription, err := naumenreq.GetTaskSumDescriptionAndRP(n.client, n.baseUrl, n.accessKey, id)
+	if err != nil {
+		return Request{}, err
+	}
+	if len(sumDescription) < 3 {
+		return Request{}, fmt.Errorf("unexpected Naumen response of %s: %v", id, sumDescription)
+	}
+
+	return Request{ID: id, ServiceCall: sumDescription[0], RP: sumDescription[1], Text: sumDescription[2]}, nil
+}
+
+func (n *Naumen) Claim(serviceCall string) error {
+	return naumen.TakeSCResponsibility(n.client, n.baseUrl, n.accessKey, serviceCall)
+}
+
+func (n *Naumen) Attach(serviceCall string, files []string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.files[serviceCall] = append(n.files[serviceCall], files...)
+
+	return nil
+}
+
+// attach collected files and set acceptance
+func (n *Naumen) Resolve(serviceCall, solution string) error {
+	n.mu.Lock()
+	files := n.files[serviceCall]
+	delete(n.files, serviceCall)
+	n.mu.Unlock()
+
+	return naumen.AttachFilesAndSetAcceptance(n.client, n.baseUrl, n.accessKey, serviceCall, solution, files)
+}
+
+func (n *Naumen) Comment(serviceCall, text string) error {
+	return naumenreq.AddComment(n.client, n.baseUrl, n.accessKey, serviceCall, text)
+}
+
+func (n *Naumen) Reject(serviceCall, reason string) error {
+	return ErrNotConfigured
+}
+
+// find service calls by discovery filter(naumen-data.json 'naumen-discovery')
+func (n *Naumen) Find() ([]string, error) {
+	return naumenreq.FindServiceCalls(n.client, n.baseUrl, n.accessKey, n.discovery)
+}
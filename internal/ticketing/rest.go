@@ -0,0 +1,494 @@
+package ticketing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// generic REST/webhook helpdesk API: every operation is HTTP request by templates
+//
+// vars of templates: BaseURL, AccessKey, ID(request id), ServiceCall, RP, Text(solution, comment or reason),
+// Files(paths of attached files); functions: json(JSON value, ex.: '{"text": {{json .Text}}}'), base(file name), urlquery
+type RestConfig struct {
+	// get request: response is JSON with fields of request(see Fields)
+	Fetch Endpoint `json:"fetch"`
+	// take responsibility on ticket, skipped if URL is empty
+	Claim Endpoint `json:"claim"`
+	// upload report files(multipart/form-data), skipped if URL is empty(resolve may use Files then)
+	Attach Endpoint `json:"attach"`
+	// resolve ticket with solution
+	Resolve Endpoint `json:"resolve"`
+	// comment & reject ticket(naumen-data.json 'ticketing.on-failure'), fail if URL is empty
+	Comment Endpoint `json:"comment"`
+	Reject  Endpoint `json:"reject"`
+	// find new requests(mode 'naumen-discovery'): response is JSON list of ids or objects with id
+	Find Endpoint `json:"find"`
+	// fields of fetch & find responses
+	Fields RestFields `json:"fields"`
+}
+
+// HTTP request of operation, success is any 2xx status
+type Endpoint struct {
+	// GET for fetch & find, POST for others by default
+	Method string `json:"method"`
+	// URL template
+	URL string `json:"url"`
+	// header templates(ex.: 'Authorization: Bearer {{.AccessKey}}')
+	Headers map[string]string `json:"headers"`
+	// body template, 'Content-Type' is 'application/json' unless it's in headers; attach sends files instead
+	Body string `json:"body"`
+	// form field of files(attach only), 'files' by default
+	FileField string `json:"file-field"`
+}
+
+// JSON paths of response fields: dot separated keys & list indexes, ex.: 'data.fields.number', 'items.0.id'
+type RestFields struct {
+	// fetch: ticket(service call) of request, request id by default
+	ServiceCall string `json:"service-call"`
+	// fetch: number of request, request id by default
+	RP string `json:"rp"`
+	// fetch: text of request form with users & dates(required)
+	Text string `json:"text"`
+	// find: list of found requests, response itself by default
+	List string `json:"list"`
+	// find: id of found request in item of list, item itself by default
+	ID string `json:"id"`
+}
+
+// data of templates
+type restData struct {
+	BaseURL     string
+	AccessKey   string
+	ID          string
+	ServiceCall string
+	RP          string
+	Text        string
+	Files       []string
+}
+
+// data to check templates
+var sampleRestData = restData{
+	BaseURL:     "https://helpdesk.example.com",
+	AccessKey:   "key",
+	ID:          "1",
+	ServiceCall: "1",
+	RP:          "1",
+	Text:        "text",
+	Files:       []string{"/reports/report.zip"},
+}
+
+// parsed templates of endpoint, nil if URL is empty
+type restEndpoint struct {
+	name      string
+	method    string
+	url       *template.Template
+	headers   map[string]*template.Template
+	body      *template.Template
+	fileField string
+}
+
+// REST/webhook helpdesk API(see RestConfig)
+type Rest struct {
+	client    *http.Client
+	baseUrl   string
+	accessKey string
+	fields    RestFields
+
+	fetch, claim, attach, resolve, comment, reject, find *restEndpoint
+
+	mu sync.Mutex
+	// attached files by service call(Files of resolve)
+	files map[string][]string
+}
+
+var restFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	"base": filepath.Base,
+}
+
+// check & parse templates: fetch(with 'text' field) & resolve are required
+func NewRest(c *http.Client, baseUrl, accessKey string, config RestConfig) (*Rest, error) {
+	if config.Fetch.URL == "" {
+		return nil, fmt.Errorf("'fetch' URL is required")
+	}
+	if config.Fields.Text == "" {
+		return nil, fmt.Errorf("'fields.text' is required")
+	}
+	if config.Resolve.URL == "" {
+		return nil, fmt.Errorf("'resolve' URL is required")
+	}
+
+	rest := &Rest{client: c, baseUrl: strings.TrimSuffix(baseUrl, "/"), accessKey: accessKey, fields: config.Fields, files: make(map[string][]string)}
+
+	endpoints := []struct {
+		name     string
+		config   Endpoint
+		method   string
+		endpoint **restEndpoint
+	}{
+		{"fetch", config.Fetch, http.MethodGet, &rest.fetch},
+		{"claim", config.Claim, http.MethodPost, &rest.claim},
+		{"attach", config.Attach, http.MethodPost, &rest.attach},
+		{"resolve", config.Resolve, http.MethodPost, &rest.resolve},
+		{"comment", config.Comment, http.MethodPost, &rest.comment},
+		{"reject", config.Reject, http.MethodPost, &rest.reject},
+		{"find", config.Find, http.MethodGet, &rest.find},
+	}
+	for _, item := range endpoints {
+		endpoint, err := parseEndpoint(item.name, item.config, item.method)
+		if err != nil {
+			return nil, err
+		}
+		*item.endpoint = endpoint
+	}
+
+	return rest, nil
+}
+
+// parse templates of endpoint, nil if URL is empty
+func parseEndpoint(name string, config Endpoint, defaultMethod string) (*restEndpoint, error) {
+	if config.URL == "" {
+		return nil, nil
+	}
+
+	endpoint := &restEndpoint{
+		name:      name,
+		method:    strings.ToUpper(config.Method),
+		headers:   make(map[string]*template.Template, len(config.Headers)),
+		fileField: config.FileField,
+	}
+	if endpoint.method == "" {
+		endpoint.method = defaultMethod
+	}
+	if endpoint.fileField == "" {
+		endpoint.fileField = "files"
+	}
+
+	var err error
+	parse := func(what, text string) *template.Template {
+		if err != nil {
+			return nil
+		}
+		var tmpl *template.Template
+		tmpl, err = template.New(name).Funcs(restFuncs).Option("missingkey=error").Parse(text)
+		if err == nil {
+			// wrong vars are found on load, not on request
+			_, err = render(tmpl, sampleRestData)
+		}
+		if err != nil {
+			err = fmt.Errorf("'%s' %s template:\n\t%v", name, what, err)
+		}
+		return tmpl
+	}
+
+	endpoint.url = parse("URL", config.URL)
+	for header, value := range config.Headers {
+		endpoint.headers[header] = parse("header "+header, value)
+	}
+	if config.Body != "" {
+		endpoint.body = parse("body", config.Body)
+	}
+
+	return endpoint, err
+}
+
+func (r *Rest) Fetch(id string) (Request, error) {
+	response, err := r.do(r.fetch, restData{ID: id}, nil)
+	if err != nil {
+		return Request{}, err
+	}
+
+	var data any
+	if err := decodeJson(response, &data); err != nil {
+		return Request{}, fmt.Errorf("failed to parse fetch response:\n\t%v", err)
+	}
+
+	request := Request{ID: id, ServiceCall: id, RP: id}
+	fields := []struct {
+		path   string
+		target *string
+	}{
+		{r.fields.ServiceCall, &request.ServiceCall},
+		{r.fields.RP, &request.RP},
+		{r.fields.Text, &request.Text},
+	}
+	for _, field := range fields {
+		if field.path == "" {
+			continue
+		}
+		if *field.target, err = lookupString(data, field.path); err != nil {
+			return Request{}, fmt.Errorf("fetch response of %s:\n\t%v", id, err)
+		}
+	}
+
+	return request, nil
+}
+
+func (r *Rest) Claim(serviceCall string) error {
+	if r.claim == nil {
+		return nil
+	}
+	_, err := r.do(r.claim, restData{ServiceCall: serviceCall}, nil)
+	return err
+}
+
+// upload files(if 'attach' is set) and keep them for resolve
+func (r *Rest) Attach(serviceCall string, files []string) error {
+	if r.attach != nil {
+		if _, err := r.do(r.attach, restData{ServiceCall: serviceCall, Files: files}, files); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.files[serviceCall] = append(r.files[serviceCall], files...)
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *Rest) Resolve(serviceCall, solution string) error {
+	r.mu.Lock()
+	files := r.files[serviceCall]
+	delete(r.files, serviceCall)
+	r.mu.Unlock()
+
+	_, err := r.do(r.resolve, restData{ServiceCall: serviceCall, Text: solution, Files: files}, nil)
+	return err
+}
+
+func (r *Rest) Comment(serviceCall, text string) error {
+	if r.comment == nil {
+		return fmt.Errorf("comment: %w", ErrNotConfigured)
+	}
+	_, err := r.do(r.comment, restData{ServiceCall: serviceCall, Text: text}, nil)
+	return err
+}
+
+func (r *Rest) Reject(serviceCall, reason string) error {
+	if r.reject == nil {
+		return fmt.Errorf("reject: %w", ErrNotConfigured)
+	}
+	_, err := r.do(r.reject, restData{ServiceCall: serviceCall, Text: reason}, nil)
+	return err
+}
+
+func (r *Rest) Find() ([]string, error) {
+	if r.find == nil {
+		return nil, fmt.Errorf("find: %w", ErrNotConfigured)
+	}
+
+	response, err := r.do(r.find, restData{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data any
+	if err := decodeJson(response, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse find response:\n\t%v", err)
+	}
+	if r.fields.List != "" {
+		if data, err = lookup(data, r.fields.List); err != nil {
+			return nil, fmt.Errorf("find response:\n\t%v", err)
+		}
+	}
+	items, ok := data.([]any)
+	if !ok {
+		return nil, fmt.Errorf("find response: list is expected, got %T", data)
+	}
+
+	result := make([]string, 0, len(items))
+	for ind, item := range items {
+		var (
+			id  string
+			err error
+		)
+		if r.fields.ID == "" {
+			id, err = scalarString(item)
+		} else {
+			id, err = lookupString(item, r.fields.ID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("find response, item %d:\n\t%v", ind, err)
+		}
+		result = append(result, id)
+	}
+
+	return result, nil
+}
+
+// make request of endpoint(files are sent as multipart/form-data) and return response body(status must be 2xx)
+func (r *Rest) do(endpoint *restEndpoint, data restData, files []string) ([]byte, error) {
+	data.BaseURL = r.baseUrl
+	data.AccessKey = r.accessKey
+
+	requestURL, err := render(endpoint.url, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to render URL:\n\t%v", endpoint.name, err)
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	switch {
+	case files != nil:
+		formData, formType, err := multipartFiles(endpoint.fileField, files)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", endpoint.name, err)
+		}
+		body, contentType = formData, formType
+	case endpoint.body != nil:
+		text, err := render(endpoint.body, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to render body:\n\t%v", endpoint.name, err)
+		}
+		body, contentType = strings.NewReader(text), "application/json"
+	}
+
+	request, err := http.NewRequest(endpoint.method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to form request:\n\t%v", endpoint.name, err)
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	for header, tmpl := range endpoint.headers {
+		value, err := render(tmpl, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to render header %s:\n\t%v", endpoint.name, header, err)
+		}
+		request.Header.Set(header, value)
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to do request:\n\t%v", endpoint.name, err)
+	}
+	defer response.Body.Close()
+
+	respBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read response:\n\t%v", endpoint.name, err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: bad response status code: %v", endpoint.name, response.Status)
+	}
+
+	return respBody, nil
+}
+
+// execute template with data
+func render(tmpl *template.Template, data restData) (string, error) {
+	var result strings.Builder
+	if err := tmpl.Execute(&result, data); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// multipart/form-data body with files in field
+func multipartFiles(field string, files []string) (io.Reader, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, path := range files {
+		if err := writeFormFile(writer, field, path); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to close form data:\n\t%v", err)
+	}
+
+	return &body, writer.FormDataContentType(), nil
+}
+
+func writeFormFile(writer *multipart.Writer, field, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file(%s):\n\t%v", path, err)
+	}
+	defer file.Close()
+
+	part, err := writer.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create form file(%s):\n\t%v", path, err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to write form file(%s):\n\t%v", path, err)
+	}
+
+	return nil
+}
+
+// JSON with numbers as is(ids must not become floats)
+func decodeJson(data []byte, target any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+// value of JSON path: dot separated keys & list indexes
+func lookup(data any, path string) (any, error) {
+	value := data
+	for _, key := range strings.Split(path, ".") {
+		switch typed := value.(type) {
+		case map[string]any:
+			item, ok := typed[key]
+			if !ok {
+				return nil, fmt.Errorf("field '%s' is not found(%s)", path, key)
+			}
+			value = item
+		case []any:
+			ind, err := strconv.Atoi(key)
+			if err != nil || ind < 0 || ind >= len(typed) {
+				return nil, fmt.Errorf("field '%s': wrong list index(%s)", path, key)
+			}
+			value = typed[ind]
+		default:
+			return nil, fmt.Errorf("field '%s': '%s' is not in object or list", path, key)
+		}
+	}
+
+	return value, nil
+}
+
+// string of JSON path value(string, number or bool)
+func lookupString(data any, path string) (string, error) {
+	value, err := lookup(data, path)
+	if err != nil {
+		return "", err
+	}
+	text, err := scalarString(value)
+	if err != nil {
+		return "", fmt.Errorf("field '%s': %v", path, err)
+	}
+	return text, nil
+}
+
+func scalarString(value any) (string, error) {
+	switch typed := value.(type) {
+	case string:
+		return typed, nil
+	case json.Number:
+		return typed.String(), nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	}
+	return "", fmt.Errorf("string or number is expected, got %T", value)
+}
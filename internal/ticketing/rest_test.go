@@ -0,0 +1,413 @@
+package ticketing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// handler of test server: request is kept, response is body & status
+type restServer struct {
+	status int
+	body   string
+
+	method      string
+	path        string
+	query       string
+	contentType string
+	auth        string
+	request     *http.Request
+	requestBody []byte
+}
+
+func newRestServer(t *testing.T, status int, body string) (*restServer, *httptest.Server) {
+	t.Helper()
+
+	rs := &restServer{status: status, body: body}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs.method = r.Method
+		rs.path = r.URL.Path
+		rs.query = r.URL.RawQuery
+		rs.contentType = r.Header.Get("Content-Type")
+		rs.auth = r.Header.Get("Authorization")
+		rs.request = r
+		rs.requestBody, _ = io.ReadAll(r.Body)
+
+		w.WriteHeader(rs.status)
+		io.WriteString(w, rs.body)
+	}))
+	t.Cleanup(server.Close)
+
+	return rs, server
+}
+
+func newTestRest(t *testing.T, server *httptest.Server, config RestConfig) *Rest {
+	t.Helper()
+
+	if config.Fetch.URL == "" {
+		config.Fetch.URL = "{{.BaseURL}}/requests/{{.ID}}"
+	}
+	if config.Fields.Text == "" {
+		config.Fields.Text = "text"
+	}
+	if config.Resolve.URL == "" {
+		config.Resolve.URL = "{{.BaseURL}}/requests/{{.ServiceCall}}/resolve"
+	}
+
+	rest, err := NewRest(server.Client(), server.URL+"/", "secret", config)
+	if err != nil {
+		t.Fatalf("NewRest() error: %v", err)
+	}
+	return rest
+}
+
+func TestRestFetch(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		fields   RestFields
+		want     Request
+		wantErr  bool
+	}{
+		{
+			name:     "ids of request by default",
+			response: `{"text": "form"}`,
+			fields:   RestFields{Text: "text"},
+			want:     Request{ID: "42", ServiceCall: "42", RP: "42", Text: "form"},
+		},
+		{
+			name:     "nested paths & list indexes",
+			response: `{"data": {"call": "sc$7", "items": [{"number": "RP-1", "form": "users & dates"}]}}`,
+			fields:   RestFields{ServiceCall: "data.call", RP: "data.items.0.number", Text: "data.items.0.form"},
+			want:     Request{ID: "42", ServiceCall: "sc$7", RP: "RP-1", Text: "users & dates"},
+		},
+		{
+			name:     "numeric ids aren't floats",
+			response: `{"id": 12345678901234567, "number": 1e3, "text": "form"}`,
+			fields:   RestFields{ServiceCall: "id", RP: "number", Text: "text"},
+			want:     Request{ID: "42", ServiceCall: "12345678901234567", RP: "1e3", Text: "form"},
+		},
+		{
+			name:     "missing field",
+			response: `{"data": {}}`,
+			fields:   RestFields{Text: "data.text"},
+			wantErr:  true,
+		},
+		{
+			name:     "wrong list index",
+			response: `{"items": [{"text": "form"}]}`,
+			fields:   RestFields{Text: "items.1.text"},
+			wantErr:  true,
+		},
+		{
+			name:     "object instead of string",
+			response: `{"text": {"value": "form"}}`,
+			fields:   RestFields{Text: "text"},
+			wantErr:  true,
+		},
+		{
+			name:     "not JSON",
+			response: `<html></html>`,
+			fields:   RestFields{Text: "text"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, server := newRestServer(t, http.StatusOK, tt.response)
+			rest := newTestRest(t, server, RestConfig{
+				Fetch: Endpoint{
+					URL:     "{{.BaseURL}}/requests/{{.ID}}",
+					Headers: map[string]string{"Authorization": "Bearer {{.AccessKey}}"},
+				},
+				Fields: tt.fields,
+			})
+
+			got, err := rest.Fetch("42")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if rs.method != http.MethodGet || rs.path != "/requests/42" || rs.auth != "Bearer secret" {
+				t.Errorf("Fetch() request = %s %s(Authorization: %s), want GET /requests/42(Authorization: Bearer secret)", rs.method, rs.path, rs.auth)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestAttachResolve(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		filepath.Join(dir, "report1.zip"): "first report",
+		filepath.Join(dir, "report2.zip"): "second report",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := []string{filepath.Join(dir, "report1.zip"), filepath.Join(dir, "report2.zip")}
+
+	rs, server := newRestServer(t, http.StatusCreated, `{}`)
+	rest := newTestRest(t, server, RestConfig{
+		Attach: Endpoint{URL: "{{.BaseURL}}/requests/{{.ServiceCall}}/files", FileField: "report"},
+		Resolve: Endpoint{
+			Method: "put",
+			URL:    "{{.BaseURL}}/requests/{{urlquery .ServiceCall}}/resolve",
+			Body:   `{"solution": {{json .Text}}, "files": [{{range $i, $f := .Files}}{{if $i}}, {{end}}{{json (base $f)}}{{end}}]}`,
+		},
+	})
+
+	// attach: files in form field
+	if err := rest.Attach("sc$1", paths); err != nil {
+		t.Fatalf("Attach() error: %v", err)
+	}
+	if rs.method != http.MethodPost || rs.path != "/requests/sc$1/files" {
+		t.Errorf("Attach() request = %s %s, want POST /requests/sc$1/files", rs.method, rs.path)
+	}
+	if !strings.HasPrefix(rs.contentType, "multipart/form-data") {
+		t.Fatalf("Attach() Content-Type = %s, want multipart/form-data", rs.contentType)
+	}
+
+	rs.request.Body = io.NopCloser(strings.NewReader(string(rs.requestBody)))
+	if err := rs.request.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("Attach() body isn't multipart form: %v", err)
+	}
+	if _, ok := rs.request.MultipartForm.File["files"]; ok {
+		t.Errorf("Attach() files are in default field, want 'report'")
+	}
+	got := make(map[string]string)
+	for _, header := range rs.request.MultipartForm.File["report"] {
+		file, err := header.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(file)
+		file.Close()
+		got[header.Filename] = string(content)
+	}
+	want := map[string]string{"report1.zip": "first report", "report2.zip": "second report"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Attach() form files = %q, want %q", got, want)
+	}
+
+	// resolve: text is JSON escaped, attached files are in Files
+	solution := "Reports: \"vpn\"\nline 2"
+	if err := rest.Resolve("sc$1", solution); err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if rs.method != http.MethodPut || rs.path != "/requests/sc$1/resolve" || rs.contentType != "application/json" {
+		t.Errorf("Resolve() request = %s %s(%s), want PUT /requests/sc$1/resolve(application/json)", rs.method, rs.path, rs.contentType)
+	}
+
+	var body struct {
+		Solution string   `json:"solution"`
+		Files    []string `json:"files"`
+	}
+	if err := json.Unmarshal(rs.requestBody, &body); err != nil {
+		t.Fatalf("Resolve() body isn't JSON(%s): %v", rs.requestBody, err)
+	}
+	if body.Solution != solution {
+		t.Errorf("Resolve() solution = %q, want %q", body.Solution, solution)
+	}
+	if !reflect.DeepEqual(body.Files, []string{"report1.zip", "report2.zip"}) {
+		t.Errorf("Resolve() files = %q, want report1.zip & report2.zip", body.Files)
+	}
+
+	// files are kept until resolve only
+	if err := rest.Resolve("sc$1", solution); err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if err := json.Unmarshal(rs.requestBody, &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Files) != 0 {
+		t.Errorf("Resolve() files of second resolve = %q, want none", body.Files)
+	}
+}
+
+func TestRestFind(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		fields   RestFields
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "list of ids",
+			response: `["data$1", 2, "data$3"]`,
+			want:     []string{"data$1", "2", "data$3"},
+		},
+		{
+			name:     "list & id paths",
+			response: `{"result": {"items": [{"meta": {"uuid": "data$1"}}, {"meta": {"uuid": 12345678901234567}}]}}`,
+			fields:   RestFields{List: "result.items", ID: "meta.uuid"},
+			want:     []string{"data$1", "12345678901234567"},
+		},
+		{
+			name:     "empty list",
+			response: `{"items": []}`,
+			fields:   RestFields{List: "items"},
+			want:     []string{},
+		},
+		{
+			name:     "not a list",
+			response: `{"items": {"id": "data$1"}}`,
+			fields:   RestFields{List: "items"},
+			wantErr:  true,
+		},
+		{
+			name:     "missing id",
+			response: `[{"id": "data$1"}, {"uuid": "data$2"}]`,
+			fields:   RestFields{ID: "id"},
+			wantErr:  true,
+		},
+		{
+			name:     "object without id path",
+			response: `[{"id": "data$1"}]`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, server := newRestServer(t, http.StatusOK, tt.response)
+			tt.fields.Text = "text"
+			rest := newTestRest(t, server, RestConfig{
+				Find:   Endpoint{URL: "{{.BaseURL}}/requests?state=new&key={{urlquery .AccessKey}}"},
+				Fields: tt.fields,
+			})
+
+			got, err := rest.Find()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Find() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if rs.method != http.MethodGet || rs.path != "/requests" || rs.query != "state=new&key=secret" {
+				t.Errorf("Find() request = %s %s?%s, want GET /requests?state=new&key=secret", rs.method, rs.path, rs.query)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestStatusErrors(t *testing.T) {
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusBadRequest, http.StatusInternalServerError} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			_, server := newRestServer(t, status, `{"text": "form"}`)
+			rest := newTestRest(t, server, RestConfig{
+				Claim:   Endpoint{URL: "{{.BaseURL}}/requests/{{.ServiceCall}}/claim"},
+				Comment: Endpoint{URL: "{{.BaseURL}}/requests/{{.ServiceCall}}/comment", Body: `{"text": {{json .Text}}}`},
+				Reject:  Endpoint{URL: "{{.BaseURL}}/requests/{{.ServiceCall}}/reject", Body: `{"reason": {{json .Text}}}`},
+				Find:    Endpoint{URL: "{{.BaseURL}}/requests"},
+			})
+			// redirects must not be followed to see status
+			rest.client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+			operations := map[string]func() error{
+				"fetch":   func() error { _, err := rest.Fetch("1"); return err },
+				"claim":   func() error { return rest.Claim("1") },
+				"resolve": func() error { return rest.Resolve("1", "done") },
+				"comment": func() error { return rest.Comment("1", "text") },
+				"reject":  func() error { return rest.Reject("1", "reason") },
+				"find":    func() error { _, err := rest.Find(); return err },
+			}
+			for name, operation := range operations {
+				err := operation()
+				if err == nil || !strings.Contains(err.Error(), "bad response status code") || !strings.HasPrefix(err.Error(), name+":") {
+					t.Errorf("%s error = %v, want bad response status code", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRestNotConfigured(t *testing.T) {
+	rs, server := newRestServer(t, http.StatusOK, `{}`)
+	rest := newTestRest(t, server, RestConfig{})
+
+	// claim & attach are skipped without URL
+	if err := rest.Claim("1"); err != nil {
+		t.Errorf("Claim() error: %v", err)
+	}
+	if err := rest.Attach("1", []string{"/not/existing.zip"}); err != nil {
+		t.Errorf("Attach() error: %v", err)
+	}
+	if rs.request != nil {
+		t.Errorf("request is made to %s %s, want none", rs.method, rs.path)
+	}
+
+	if err := rest.Comment("1", "text"); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Comment() error = %v, want ErrNotConfigured", err)
+	}
+	if err := rest.Reject("1", "reason"); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Reject() error = %v, want ErrNotConfigured", err)
+	}
+	if _, err := rest.Find(); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Find() error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestNewRest(t *testing.T) {
+	valid := func() RestConfig {
+		return RestConfig{
+			Fetch:   Endpoint{URL: "{{.BaseURL}}/requests/{{.ID}}"},
+			Resolve: Endpoint{URL: "{{.BaseURL}}/requests/{{.ServiceCall}}/resolve", Body: `{"text": {{json .Text}}}`},
+			Fields:  RestFields{Text: "text"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		change  func(*RestConfig)
+		wantErr string
+	}{
+		{name: "valid", change: func(*RestConfig) {}},
+		{name: "no fetch URL", change: func(c *RestConfig) { c.Fetch.URL = "" }, wantErr: "'fetch' URL"},
+		{name: "no text field", change: func(c *RestConfig) { c.Fields.Text = "" }, wantErr: "'fields.text'"},
+		{name: "no resolve URL", change: func(c *RestConfig) { c.Resolve.URL = "" }, wantErr: "'resolve' URL"},
+		{name: "syntax error", change: func(c *RestConfig) { c.Claim.URL = "{{.BaseURL}/claim" }, wantErr: "'claim' URL template"},
+		{name: "unknown var", change: func(c *RestConfig) { c.Resolve.Body = `{"text": {{json .Solution}}}` }, wantErr: "'resolve' body template"},
+		{name: "unknown function", change: func(c *RestConfig) { c.Comment.URL = "{{.BaseURL}}/{{lower .ServiceCall}}" }, wantErr: "'comment' URL template"},
+		{
+			name: "wrong header",
+			change: func(c *RestConfig) {
+				c.Find = Endpoint{URL: "{{.BaseURL}}", Headers: map[string]string{"Authorization": "Bearer {{.Key}}"}}
+			},
+			wantErr: "'find' header Authorization template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid()
+			tt.change(&config)
+
+			_, err := NewRest(http.DefaultClient, "https://helpdesk", "key", config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("NewRest() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewRest() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
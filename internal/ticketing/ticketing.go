@@ -0,0 +1,42 @@
+package ticketing
+
+import "errors"
+
+// operation of helpdesk API isn't configured(ex.: 'comment' URL of REST API is empty)
+var ErrNotConfigured = errors.New("operation is not configured")
+
+// request of helpdesk(db value) to get reports for
+type Request struct {
+	// id of request in db, ex.: Naumen data$..., serviceCall$...
+	ID string
+	// ticket to claim, attach reports to & resolve; several requests may belong to one ticket
+	ServiceCall string
+	// number(title) of request, ex.: Naumen RP
+	RP string
+	// text of request form with users & dates, ex.: Naumen sumDescription
+	Text string
+}
+
+// helpdesk API of mode 'naumen': Naumen(NewNaumen) or any REST/webhook API(NewRest)
+//
+// tickets are service calls of requests(Request.ServiceCall)
+type Ticketing interface {
+	// get request of db value
+	Fetch(id string) (Request, error)
+	// take responsibility on ticket
+	Claim(serviceCall string) error
+	// attach report files to ticket
+	Attach(serviceCall string, files []string) error
+	// resolve ticket with solution text(attached files are delivered)
+	Resolve(serviceCall, solution string) error
+	// add comment to ticket
+	Comment(serviceCall, text string) error
+	// reject ticket with reason(request can't be processed)
+	Reject(serviceCall, reason string) error
+}
+
+// helpdesk API which finds new requests(mode 'naumen-discovery')
+type Finder interface {
+	// ids of new requests
+	Find() ([]string, error)
+}